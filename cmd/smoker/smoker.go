@@ -20,25 +20,50 @@ func main() {
 	flags, err := cmdoptions.InstallFlags(version.String(), os.Stdout)
 	exitIfError(err)
 
-	testsuite, err := loader.LoadTestsuite(flags.TestsuiteFile)
+	testsuite, err := loader.LoadTestsuites(flags.TestsuiteFiles, flags.Format, flags.Variables)
 	exitIfError(err)
 
 	runner := runner.NewRunner(flags.Workers, flags.Timeout, flags.StopOnFailure, os.Stdout, os.Stderr)
+	runner.SetRetryPolicy(flags.Retry)
+	exitIfError(runner.SetFilter(flags.Filter))
 	requester := requester.NewRequester(flags.Timeout, fmt.Sprintf("smoker/%s", version.String()))
+	exitIfError(requester.SetNetwork(flags.Network))
 
-	sigsChan := make(chan os.Signal)
+	reportFiles, err := addReports(runner, flags.Reports)
+	exitIfError(err)
+
+	// The first signal cancels the requests in flight and lets the runner
+	// report them, a second one exits right away.
+	interrupted := make(chan struct{})
+	sigsChan := make(chan os.Signal, 1)
 	signal.Notify(sigsChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigsChan
 
+		close(interrupted)
 		runner.Stop()
 
+		<-sigsChan
+
 		exitWithError(errors.New("Interrupted"))
 	}()
 
 	ok, err := runner.Run(requester, testsuite)
+
+	for _, f := range reportFiles {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
 	exitIfError(err)
 
+	select {
+	case <-interrupted:
+		exitWithError(errors.New("Interrupted"))
+	default:
+	}
+
 	fmt.Println("Done")
 
 	if !ok {
@@ -46,6 +71,25 @@ func main() {
 	}
 }
 
+func addReports(r *runner.Runner, reports []cmdoptions.Report) ([]*os.File, error) {
+	var files []*os.File
+
+	for _, report := range reports {
+		f, err := os.Create(report.Path)
+		if err != nil {
+			return files, fmt.Errorf("unable to create report file: %w", err)
+		}
+
+		files = append(files, f)
+
+		if err := r.AddReport(report.Format, f); err != nil {
+			return files, err
+		}
+	}
+
+	return files, nil
+}
+
 func exitIfError(err error) {
 	if err == nil {
 		return
@@ -3,21 +3,47 @@ package cmdoptions
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"strings"
 	"time"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/loader"
+	"github.com/amad/smoker/reporter"
+	"github.com/amad/smoker/runner"
 )
 
 // InputOptions holds input arguments.
 type InputOptions struct {
-	// TestsuiteFile is path of the testsuite JSON file.
-	TestsuiteFile string
+	// TestsuiteFiles are files, directories or glob patterns of JSON or YAML testsuites.
+	TestsuiteFiles []string
 	// Workers represents number of concurrent workers.
 	Workers int
-	// Timeout is the maximum duration for each HTTP request.
+	// Timeout is the default maximum duration for each HTTP request.
 	Timeout time.Duration
 	// StopOnFailure force exits on first error or failure.
 	StopOnFailure bool
+	// Format is the testsuite file format. It is detected from the file extension when empty.
+	Format string
+	// Variables are set with -var flags and replace ${NAME} references in test cases.
+	Variables map[string]string
+	// Reports are report files to write when the run finishes.
+	Reports []Report
+	// Retry is the retry policy of the run. Only the fields of the flags that were set are filled.
+	Retry core.RetryPolicy
+	// Filter selects the test cases to run with -tags, -exclude-tags, -run and -forbid-only.
+	Filter runner.Filter
+	// Network routes the requests with -proxy, -resolve and -connect-to.
+	Network core.Network
+}
+
+// Report is a report file requested with -report format=path.
+type Report struct {
+	Format string
+	Path   string
 }
 
 var usage = `
@@ -26,18 +52,46 @@ Usage: smoker [options...]
 Example:
   smoker -testsuite smoketestsuite-api.json
   smoker -testsuite smoketestsuite-web.json -workers 4 -timeout 5 -stop-on-failure
+  smoker -testsuite smoketestsuite-api.yaml
+  smoker -testsuite suites/ -testsuite "smoke-*.json"
+  smoker -testsuite smoketestsuite-api.json -var host=staging.example.com -var token=secret
+  smoker -testsuite smoketestsuite-api.json -report junit=smoker-results.xml -report jsonl=smoker-results.jsonl
+  smoker -testsuite smoketestsuite-api.json -retries 3 -retry-delay 500ms -backoff exponential -retry-on network,503
+  smoker -testsuite smoketestsuite-api.json -tags critical -exclude-tags slow -run "^Login"
+  smoker -testsuite smoketestsuite-api.json -resolve api.example.com:443:10.0.0.5 -proxy http://proxy:3128
 
 Options:
-  -testsuite        Testsuite file in JSON format to read test cases.
+  -testsuite        Testsuite file in JSON or YAML format to read test cases. (can be repeated, accepts directories and glob patterns)
+  -format           Testsuite file format: json or yaml. (Default is detected by file extension: .yaml and .yml are YAML, anything else is JSON)
   -workers          Number of workers to send requests concurrently. (accepts integer value >= 1. Default is 1. 0 is not allowed)
-  -timeout          Set timeout per request in seconds. (accepts integer value >= 1. Default is 10. 0 is not allowed)
+  -timeout          Set the default timeout per request in seconds, testsuites and test cases can override it. (accepts integer value >= 1. Default is 10. 0 is not allowed)
   -stop-on-failure  Stop execution upon first error or failure.
+  -var              Set a variable as key=value to replace ${key} in test cases. (can be repeated, overrides environment and testsuite variables)
+  -report           Write a report as format=path. Supported formats: junit, json and jsonl. (can be repeated)
+  -retries          Number of times a failed test case is retried. (accepts integer value >= 0. Default is 0)
+  -retry-delay      Delay before the first retry, such as 500ms or 2s. (Default is 1s)
+  -backoff          Delay between retries: constant, exponential or exponential-jitter. (Default is constant)
+  -retry-on         Outcomes to retry: network, status codes such as 503 or classes such as 5xx. (comma separated, can be repeated. Default is network,5xx)
+  -tags             Only run test cases with at least one of these tags. (comma separated, can be repeated)
+  -exclude-tags     Do not run test cases with any of these tags. (comma separated, can be repeated)
+  -run              Only run test cases whose name matches this regular expression.
+  -forbid-only      Fail the run if a test case is marked only. (use it in CI)
+  -proxy            Send requests through an HTTP, HTTPS or SOCKS5 proxy, such as http://proxy:3128 or socks5://localhost:1080.
+  -resolve          Connect to an address instead of the address of a host, as host:port:address. (comma separated, can be repeated)
+  -connect-to       Connect to another host and port, as host:port:connect-host:connect-port. (comma separated, can be repeated)
   -version          Prints the version and exits.
 
 Visit: https://github.com/amad/smoker
 `
 
 var versionFlag bool
+var variables stringList
+var reports stringList
+var retryOn stringList
+var tags stringList
+var excludeTags stringList
+var resolve stringList
+var connectTo stringList
 
 // InstallFlags adds CLI flags and validates user input.
 func InstallFlags(version string, stdout io.StringWriter) (*InputOptions, error) {
@@ -50,7 +104,7 @@ func InstallFlags(version string, stdout io.StringWriter) (*InputOptions, error)
 		os.Exit(0)
 	}
 
-	if flags.TestsuiteFile == "" {
+	if len(flags.TestsuiteFiles) == 0 {
 		return &flags, errors.New("-testsuite is required")
 	}
 
@@ -62,17 +116,103 @@ func InstallFlags(version string, stdout io.StringWriter) (*InputOptions, error)
 		return &flags, errors.New("-timeout only accept a number >= 1")
 	}
 
+	if flags.Format != "" && flags.Format != loader.FormatJSON && flags.Format != loader.FormatYAML {
+		return &flags, errors.New("-format only accept json or yaml")
+	}
+
+	for _, v := range variables {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return &flags, errors.New("-var only accept key=value")
+		}
+
+		if flags.Variables == nil {
+			flags.Variables = make(map[string]string)
+		}
+
+		flags.Variables[kv[0]] = kv[1]
+	}
+
+	for _, r := range reports {
+		kv := strings.SplitN(r, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return &flags, errors.New("-report only accept format=path")
+		}
+
+		switch kv[0] {
+		case reporter.FormatJUnit, reporter.FormatJSON, reporter.FormatJSONLines:
+		default:
+			return &flags, fmt.Errorf("-report does not support format %q", kv[0])
+		}
+
+		flags.Reports = append(flags.Reports, Report{Format: kv[0], Path: kv[1]})
+	}
+
+	if flags.Retry.Retries != nil && *flags.Retry.Retries < 0 {
+		return &flags, errors.New("-retries only accept a number >= 0")
+	}
+
+	if flags.Retry.RetryDelay != nil && *flags.Retry.RetryDelay < 0 {
+		return &flags, errors.New("-retry-delay only accept a duration >= 0")
+	}
+
+	switch flags.Retry.Backoff {
+	case "", runner.BackoffConstant, runner.BackoffExponential, runner.BackoffExponentialJitter:
+	default:
+		return &flags, errors.New("-backoff only accept constant, exponential or exponential-jitter")
+	}
+
+	for _, outcome := range retryOn.split() {
+		if !runner.ValidRetryOutcome(outcome) {
+			return &flags, errors.New("-retry-on only accept network, status codes or classes such as 5xx")
+		}
+
+		flags.Retry.RetryOn = append(flags.Retry.RetryOn, outcome)
+	}
+
+	flags.Filter.Tags = tags.split()
+	flags.Filter.ExcludeTags = excludeTags.split()
+
+	flags.Network.Resolve = resolve.split()
+	flags.Network.ConnectTo = connectTo.split()
+
+	if _, err := regexp.Compile(flags.Filter.Run); err != nil {
+		return &flags, fmt.Errorf("-run only accept a valid regular expression: %w", err)
+	}
+
 	return &flags, nil
 }
 
 func addOptions(flags *InputOptions, stdout io.StringWriter) {
-	var timeout int
+	var timeout, retries int
+	var retryDelay time.Duration
 
 	flag.IntVar(&flags.Workers, "workers", 1, "")
 	flag.IntVar(&timeout, "timeout", 10, "")
 	flag.BoolVar(&versionFlag, "version", false, "")
-	flag.StringVar(&flags.TestsuiteFile, "testsuite", "", "")
+	flag.Var((*stringList)(&flags.TestsuiteFiles), "testsuite", "")
 	flag.BoolVar(&flags.StopOnFailure, "stop-on-failure", false, "")
+	flag.StringVar(&flags.Format, "format", "", "")
+	variables = nil
+	flag.Var(&variables, "var", "")
+	reports = nil
+	flag.Var(&reports, "report", "")
+	flag.IntVar(&retries, "retries", 0, "")
+	flag.DurationVar(&retryDelay, "retry-delay", time.Second, "")
+	flag.StringVar(&flags.Retry.Backoff, "backoff", "", "")
+	retryOn = nil
+	flag.Var(&retryOn, "retry-on", "")
+	tags = nil
+	flag.Var(&tags, "tags", "")
+	excludeTags = nil
+	flag.Var(&excludeTags, "exclude-tags", "")
+	flag.StringVar(&flags.Filter.Run, "run", "", "")
+	flag.BoolVar(&flags.Filter.ForbidOnly, "forbid-only", false, "")
+	flag.StringVar(&flags.Network.Proxy, "proxy", "", "")
+	resolve = nil
+	flag.Var(&resolve, "resolve", "")
+	connectTo = nil
+	flag.Var(&connectTo, "connect-to", "")
 
 	flag.Usage = func() {
 		stdout.WriteString(usage)
@@ -82,4 +222,48 @@ func addOptions(flags *InputOptions, stdout io.StringWriter) {
 	flag.Parse()
 
 	flags.Timeout = time.Duration(timeout) * time.Second
+
+	// Retry options that are not set are left empty, so test cases and
+	// the runner can tell them apart from explicit values.
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "retries":
+			flags.Retry.Retries = &retries
+		case "retry-delay":
+			delay := core.Duration(retryDelay)
+			flags.Retry.RetryDelay = &delay
+		}
+	})
+}
+
+// stringList is a flag value that collects repeated flags.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	*l = append(*l, value)
+
+	return nil
+}
+
+// split returns the comma separated values of all the flags.
+func (l stringList) split() []string {
+	var values []string
+
+	for _, v := range l {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+
+	return values
 }
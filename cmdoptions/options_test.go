@@ -5,26 +5,50 @@ import (
 	"flag"
 	"os"
 	"os/exec"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/runner"
 )
 
 func TestInstallFlags(t *testing.T) {
 	t.Parallel()
 
+	three, zero := 3, 0
+	retryDelay := core.Duration(500 * time.Millisecond)
+
 	tt := []struct {
 		name      string
 		args      []string
 		options   *InputOptions
 		expectErr string
 	}{
-		{"flagset1", []string{"app", "-testsuite", "test"}, &InputOptions{"test", 1, time.Duration(10) * time.Second, false}, ""},
-		{"flagset2", []string{"app", "-testsuite", "test", "-workers", "2", "-timeout", "5", "-stop-on-failure"}, &InputOptions{"test", 2, time.Duration(5) * time.Second, true}, ""},
+		{"flagset1", []string{"app", "-testsuite", "test"}, &InputOptions{[]string{"test"}, 1, time.Duration(10) * time.Second, false, "", nil, nil, core.RetryPolicy{}, runner.Filter{}, core.Network{}}, ""},
+		{"flagset2", []string{"app", "-testsuite", "test", "-workers", "2", "-timeout", "5", "-stop-on-failure"}, &InputOptions{[]string{"test"}, 2, time.Duration(5) * time.Second, true, "", nil, nil, core.RetryPolicy{}, runner.Filter{}, core.Network{}}, ""},
+		{"multiple_testsuites", []string{"app", "-testsuite", "a.json", "-testsuite", "suites/", "-testsuite", "*.yaml"}, &InputOptions{[]string{"a.json", "suites/", "*.yaml"}, 1, time.Duration(10) * time.Second, false, "", nil, nil, core.RetryPolicy{}, runner.Filter{}, core.Network{}}, ""},
+		{"format", []string{"app", "-testsuite", "test", "-format", "yaml"}, &InputOptions{[]string{"test"}, 1, time.Duration(10) * time.Second, false, "yaml", nil, nil, core.RetryPolicy{}, runner.Filter{}, core.Network{}}, ""},
 		{"no_args", []string{"app", ""}, nil, "-testsuite is required"},
 		{"no_testsuite", []string{"app", "-stop-on-failure", "0"}, nil, "-testsuite is required"},
 		{"invalid_workers", []string{"app", "-workers", "0", "-testsuite", "test"}, nil, "-workers only accept a number >= 1"},
 		{"invalid_timeout", []string{"app", "-timeout", "0", "-testsuite", "test"}, nil, "-timeout only accept a number >= 1"},
+		{"variables", []string{"app", "-testsuite", "test", "-var", "host=example.com", "-var", "query=a=b", "-var", "empty="}, &InputOptions{[]string{"test"}, 1, time.Duration(10) * time.Second, false, "", map[string]string{"host": "example.com", "query": "a=b", "empty": ""}, nil, core.RetryPolicy{}, runner.Filter{}, core.Network{}}, ""},
+		{"reports", []string{"app", "-testsuite", "test", "-report", "junit=out/results.xml", "-report", "junit=a=b.xml", "-report", "json=results.json", "-report", "jsonl=results.jsonl"}, &InputOptions{[]string{"test"}, 1, time.Duration(10) * time.Second, false, "", nil, []Report{{"junit", "out/results.xml"}, {"junit", "a=b.xml"}, {"json", "results.json"}, {"jsonl", "results.jsonl"}}, core.RetryPolicy{}, runner.Filter{}, core.Network{}}, ""},
+		{"retries", []string{"app", "-testsuite", "test", "-retries", "3", "-retry-delay", "500ms", "-backoff", "exponential-jitter", "-retry-on", "network, 503", "-retry-on", "5xx"}, &InputOptions{[]string{"test"}, 1, time.Duration(10) * time.Second, false, "", nil, nil, core.RetryPolicy{Retries: &three, RetryDelay: &retryDelay, Backoff: "exponential-jitter", RetryOn: []string{"network", "503", "5xx"}}, runner.Filter{}, core.Network{}}, ""},
+		{"no_retries", []string{"app", "-testsuite", "test", "-retries", "0"}, &InputOptions{[]string{"test"}, 1, time.Duration(10) * time.Second, false, "", nil, nil, core.RetryPolicy{Retries: &zero}, runner.Filter{}, core.Network{}}, ""},
+		{"invalid_retries", []string{"app", "-testsuite", "test", "-retries", "-1"}, nil, "-retries only accept a number >= 0"},
+		{"invalid_retry_delay", []string{"app", "-testsuite", "test", "-retry-delay", "-1s"}, nil, "-retry-delay only accept a duration >= 0"},
+		{"invalid_backoff", []string{"app", "-testsuite", "test", "-backoff", "linear"}, nil, "-backoff only accept constant, exponential or exponential-jitter"},
+		{"invalid_retry_on", []string{"app", "-testsuite", "test", "-retry-on", "network,timeout"}, nil, "-retry-on only accept network, status codes or classes such as 5xx"},
+		{"filter", []string{"app", "-testsuite", "test", "-tags", "critical, smoke", "-tags", "api", "-exclude-tags", "slow", "-run", "^Login", "-forbid-only"}, &InputOptions{[]string{"test"}, 1, time.Duration(10) * time.Second, false, "", nil, nil, core.RetryPolicy{}, runner.Filter{Tags: []string{"critical", "smoke", "api"}, ExcludeTags: []string{"slow"}, Run: "^Login", ForbidOnly: true}, core.Network{}}, ""},
+		{"network", []string{"app", "-testsuite", "test", "-proxy", "socks5://localhost:1080", "-resolve", "api.example.com:443:10.0.0.5,example.com:443:10.0.0.6", "-connect-to", "api.example.com:443::8443"}, &InputOptions{[]string{"test"}, 1, time.Duration(10) * time.Second, false, "", nil, nil, core.RetryPolicy{}, runner.Filter{}, core.Network{Proxy: "socks5://localhost:1080", Resolve: []string{"api.example.com:443:10.0.0.5", "example.com:443:10.0.0.6"}, ConnectTo: []string{"api.example.com:443::8443"}}}, ""},
+		{"invalid_run", []string{"app", "-testsuite", "test", "-run", "("}, nil, "-run only accept a valid regular expression: error parsing regexp: missing closing ): `(`"},
+		{"invalid_report", []string{"app", "-testsuite", "test", "-report", "junit"}, nil, "-report only accept format=path"},
+		{"unsupported_report", []string{"app", "-testsuite", "test", "-report", "html=index.html"}, nil, "-report does not support format \"html\""},
+		{"invalid_var", []string{"app", "-testsuite", "test", "-var", "host"}, nil, "-var only accept key=value"},
+		{"invalid_format", []string{"app", "-format", "xml", "-testsuite", "test"}, nil, "-format only accept json or yaml"},
 	}
 
 	for _, tc := range tt {
@@ -57,7 +81,7 @@ func TestInstallFlags(t *testing.T) {
 			}
 
 			if tc.options != nil {
-				if *tc.options != *options {
+				if !reflect.DeepEqual(tc.options, options) {
 					t.Fatalf("Options do not match\nexpected: %+v\nreceived: %+v", tc.options, options)
 				}
 			}
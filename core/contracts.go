@@ -1,5 +1,11 @@
 package core
 
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
 // Runner defines interface of a test runner.
 type Runner interface {
 	Run(requester Requester, testsuite *Testsuite) (noFailure bool, err error)
@@ -8,7 +14,67 @@ type Runner interface {
 
 // Requester defines interface of testcase handler.
 type Requester interface {
-	Request(tc TestCase) (bool, error)
+	// Request sends the request of a test case and checks the response.
+	// It returns when ctx is cancelled, with an error wrapping ctx.Err().
+	Request(ctx context.Context, tc TestCase) (Result, error)
+}
+
+// Result holds the outcome of a request made for a test case.
+type Result struct {
+	Passed bool
+	// StatusCode is the response status code, zero when no response was received.
+	StatusCode int
+	// NetworkError is set when the request was sent but failed before a
+	// response was received, such as on a refused connection or a timeout.
+	// It is not set when the test case is invalid.
+	NetworkError bool
+	// RemoteAddr is the address the request was sent to, empty when no
+	// connection was made.
+	RemoteAddr string
+	// Captured holds the values extracted from the response.
+	Captured map[string]string
+}
+
+// Reporter receives the events of a testsuite run. The runner delivers
+// events one at a time, so implementations do not need to be safe for
+// concurrent use. An error returned by a reporter does not stop the run,
+// the first one is returned by the runner when the run finishes.
+type Reporter interface {
+	SuiteStarted(s SuiteStart) error
+	TestStarted(t TestStart) error
+	TestFinished(r *TestReport) error
+	SuiteFinished(s SuiteSummary) error
+}
+
+// SuiteStart describes a testsuite run that is about to start.
+type SuiteStart struct {
+	Tests int
+	// Filtered is the number of test cases in the testsuite that are not run.
+	Filtered int
+	Workers  int
+	// Timeout is the default timeout of the requests, test cases can override it.
+	Timeout time.Duration
+	// TestCases are the test cases of the run in testsuite order, without
+	// the filtered test cases.
+	TestCases     []TestCase
+	StopOnFailure bool
+	StartedAt     time.Time
+}
+
+// TestStart describes a test case that is about to send its request.
+type TestStart struct {
+	// Index is the position of the test case in the testsuite, starting at 1.
+	Index     int
+	TestCase  TestCase
+	StartedAt time.Time
+}
+
+// SuiteSummary holds the results of a finished testsuite run.
+type SuiteSummary struct {
+	StartedAt time.Time
+	Elapsed   time.Duration
+	// Reports holds the test reports in the order the test cases finished.
+	Reports []*TestReport
 }
 
 // TestResult defines interface to check if test has passed and
@@ -20,22 +86,367 @@ type TestResult interface {
 
 // Testsuite hold all fields realted to testsuite and all testcases.
 type Testsuite struct {
-	Tests []TestCase `json:"tests"`
+	Tests     []TestCase        `json:"tests"`
+	Variables map[string]string `json:"variables"`
+	// Timeout is an alias of Defaults.Timeout, the loader moves it there.
+	Timeout Duration `json:"timeout"`
+	// Defaults holds fields shared by the test cases in the testsuite file.
+	Defaults Defaults `json:"defaults"`
+}
+
+// Defaults are used for the fields a test case does not set. The loader
+// merges them into the test cases of the testsuite file.
+type Defaults struct {
+	// BaseURL is prepended to test case URLs without a scheme.
+	BaseURL string `json:"baseURL"`
+	// Headers are sent with every request, test cases can override each header.
+	Headers map[string]string `json:"headers"`
+	Method  string            `json:"method"`
+	Timeout Duration          `json:"timeout"`
+	Session string            `json:"session"`
+	// TLS configures the TLS connections of the test cases, test cases can
+	// override each field.
+	TLS TLS `json:"tls"`
+	// Network routes the connections of the test cases, test cases can
+	// override each field.
+	Network
+	FollowRedirects *Redirects `json:"followRedirects"`
+	// Auth authenticates the requests of the test cases that do not set auth.
+	Auth *Auth `json:"auth"`
+	// Sign signs the requests of the test cases that do not set sign.
+	Sign *Signing `json:"sign"`
+	// Assertions are checked for every test case, test cases can override each assertion.
+	Assertions Assertions `json:"assertions"`
 }
 
 // TestCase specifies one test case.
 type TestCase struct {
-	Name       string            `json:"name"`
-	URL        string            `json:"url"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// BaseURL is the base URL of the defaults, set by the loader when the URL
+	// starts with a captured value. The runner prepends it once the URL is
+	// expanded.
+	BaseURL    string            `json:"-"`
 	Method     string            `json:"method"`
 	Headers    map[string]string `json:"headers"`
 	Body       string            `json:"body"`
 	Assertions Assertions        `json:"assertions"`
+	// Extract maps variable names to values captured from the response.
+	// Later test cases can reference them as ${name}.
+	Extract map[string]Extractor `json:"extract"`
+	// DependsOn lists names of test cases that must pass before this one runs.
+	DependsOn []string `json:"dependsOn"`
+	// Session is the name of a cookie session. Test cases of the same session
+	// share their cookies, test cases without a session do not keep cookies.
+	Session string `json:"session"`
+	// Tags are labels used to select which test cases to run.
+	Tags []string `json:"tags"`
+	// Skip is the reason not to run the test case. It is reported as skipped.
+	Skip string `json:"skip"`
+	// Only limits the run to the test cases marked only.
+	Only bool `json:"only"`
+	// Timeout is the maximum duration of the request, the timeout of the
+	// run is used when it is zero.
+	Timeout Duration `json:"timeout"`
+	// DefaultTimeout is the default timeout of the testsuite file of the
+	// test case, set by the loader.
+	DefaultTimeout Duration `json:"-"`
+	// TLS configures the TLS connection of the request.
+	TLS TLS `json:"tls"`
+	// Network routes the connection of the request, in addition to the
+	// network options set for the run.
+	Network
+	// FollowRedirects is the maximum number of redirects followed, 10 when
+	// it is nil. With 0 the redirect response is checked by the assertions.
+	FollowRedirects *Redirects `json:"followRedirects"`
+	// Auth authenticates the request. Use an empty object to not use the
+	// auth of the testsuite defaults.
+	Auth *Auth `json:"auth"`
+	// Sign signs the request. Use an empty object to not use the signing of
+	// the testsuite defaults.
+	Sign *Signing `json:"sign"`
+	// RetryPolicy overrides the retry options set for the run.
+	RetryPolicy
+	// Source is the testsuite file the test case was loaded from.
+	Source string `json:"-"`
+}
+
+// RetryPolicy controls how a failed test case is retried. Fields that are
+// not set use the value of the run, see the runner for the defaults.
+type RetryPolicy struct {
+	// Retries is the number of times a failed test case is sent again.
+	Retries *int `json:"retries"`
+	// RetryDelay is the delay before the first retry.
+	RetryDelay *Duration `json:"retryDelay"`
+	// Backoff is constant, exponential or exponential-jitter.
+	Backoff string `json:"backoff"`
+	// RetryOn lists the outcomes that are retried: network for requests that
+	// did not receive a response, or response status codes such as 503 or 5xx.
+	RetryOn Outcomes `json:"retryOn"`
+}
+
+// Auth sets the Authorization header of a request. At most one of Basic,
+// Bearer or OAuth2 can be set. An Authorization header set in the headers of
+// the test case takes precedence.
+type Auth struct {
+	Basic  *BasicAuth  `json:"basic"`
+	Bearer *BearerAuth `json:"bearer"`
+	OAuth2 *OAuth2Auth `json:"oauth2"`
+}
+
+// BasicAuth sends a username and a password.
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// BearerAuth sends a bearer token. Exactly one of Token, Env or File must
+// be set. Env and File are read before each request, so a token can be
+// rotated during a run.
+type BearerAuth struct {
+	Token string `json:"token"`
+	// Env is the name of an environment variable holding the token.
+	Env string `json:"env"`
+	// File is the path of a file holding the token, relative to the testsuite file.
+	File string `json:"file"`
+}
+
+// OAuth2Auth sends a bearer token fetched with the OAuth2 client credentials
+// grant. Tokens are shared by the test cases with the same settings and are
+// fetched again before they expire.
+type OAuth2Auth struct {
+	TokenURL     string   `json:"tokenURL"`
+	ClientID     string   `json:"clientID"`
+	ClientSecret string   `json:"clientSecret"`
+	Scopes       []string `json:"scopes"`
+}
+
+// Signing signs a request once it is complete, including a hash of its body.
+// At most one of SigV4 or HMAC can be set.
+type Signing struct {
+	SigV4 *SigV4Signing `json:"sigv4"`
+	HMAC  *HMACSigning  `json:"hmac"`
+}
+
+// SigV4Signing signs a request with AWS Signature Version 4. The credentials
+// are read from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN environment variables.
+type SigV4Signing struct {
+	// Region defaults to the AWS_REGION or AWS_DEFAULT_REGION environment variable.
+	Region string `json:"region"`
+	// Service is the signing name of the service, such as execute-api.
+	Service string `json:"service"`
 }
 
+// HMACSigning signs a request with an HMAC of a string built from a template.
+// Templates use placeholders such as {method}, {path} or {bodySHA256}.
+type HMACSigning struct {
+	Secret string `json:"secret"`
+	// Algorithm is sha1, sha256 or sha512, sha256 by default.
+	Algorithm string `json:"algorithm"`
+	// Encoding of the signature is hex or base64, hex by default.
+	Encoding string `json:"encoding"`
+	// Template is the string to sign.
+	Template string `json:"template"`
+	// Header receives the signature, Authorization by default.
+	Header string `json:"header"`
+	// Value is the template of the header value, {signature} by default.
+	Value string `json:"value"`
+	// Headers are set before signing, their values can use placeholders.
+	Headers map[string]string `json:"headers"`
+}
+
+// Redirects is a maximum number of redirects. In a testsuite it is true to
+// follow up to DefaultRedirects, false to not follow redirects, or a number.
+type Redirects int
+
+// DefaultRedirects is the number of redirects followed by default.
+const DefaultRedirects Redirects = 10
+
+// Network routes the connection of a request, like the curl options of the
+// same name. Mappings are applied to the address smoker connects to, which
+// is the proxy when a proxy is set.
+type Network struct {
+	// Proxy is the URL of an HTTP, HTTPS or SOCKS5 proxy, such as
+	// http://proxy:3128 or socks5://localhost:1080. The proxy of the
+	// environment is used when it is empty.
+	Proxy string `json:"proxy"`
+	// Resolve lists host:port:address mappings, to connect to address instead
+	// of the address of host when the URL has this host and port.
+	Resolve []string `json:"resolve"`
+	// ConnectTo lists host:port:connect-host:connect-port mappings, to connect
+	// to connect-host:connect-port instead of host:port. Empty fields match any
+	// host or port and keep the host or port of the URL.
+	ConnectTo []string `json:"connectTo"`
+}
+
+// TLS configures the TLS connection of a request. The default configuration
+// of the system is used for the fields that are not set.
+type TLS struct {
+	// CA is the path of a PEM bundle of certificate authorities trusted to
+	// verify the server certificate, instead of the system ones.
+	CA string `json:"ca"`
+	// Cert and Key are the paths of the PEM client certificate and its
+	// private key, sent when the server asks for a client certificate.
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	// ServerName is used to verify the server certificate and is sent as
+	// SNI, the host of the URL when empty.
+	ServerName string `json:"serverName"`
+	// MinVersion is the minimum TLS version: 1.0, 1.1, 1.2 or 1.3.
+	MinVersion string `json:"minVersion"`
+	// InsecureSkipVerify disables the verification of the server certificate.
+	InsecureSkipVerify *bool `json:"insecureSkipVerify"`
+}
+
+// Outcomes is a list of outcomes of a request, such as network or a status code.
+type Outcomes []string
+
 // Assertions describes expectations on each test case.
 type Assertions struct {
-	StatusCode int               `json:"statusCode"`
-	Body       []string          `json:"body"`
-	Headers    map[string]string `json:"headers"`
+	// StatusCode lists the accepted response status codes, 200 when empty.
+	StatusCode StatusCodes `json:"statusCode"`
+	Body       []string    `json:"body"`
+	// Headers maps response header names to expectations on their values.
+	Headers map[string]HeaderAssertion `json:"headers"`
+	// BodyNotContains lists regular expressions that must not match the response body.
+	BodyNotContains []string `json:"bodyNotContains"`
+	// HeadersAbsent lists names of headers the response must not have.
+	HeadersAbsent []string `json:"headersAbsent"`
+	// Cookies maps cookie names to expectations on the cookies set by the response.
+	Cookies map[string]CookieAssertion `json:"cookies"`
+	// JSON maps JSONPath expressions to expectations on the JSON response body.
+	JSON map[string]JSONAssertion `json:"json"`
+	// JSONSchema is a JSON Schema the JSON response body must be valid against.
+	// In a testsuite it is an inline schema or a path to a JSON or YAML schema
+	// file relative to the testsuite file, which the loader replaces with its contents.
+	JSONSchema json.RawMessage `json:"jsonSchema"`
+	// MaxDuration is the maximum time to receive the complete response.
+	MaxDuration Duration `json:"maxDuration"`
+	// MaxTimeToFirstByte is the maximum time until the first byte of the response.
+	MaxTimeToFirstByte Duration `json:"maxTimeToFirstByte"`
+	// MaxConnectTime is the maximum time to open the TCP connection. It is not
+	// checked when an idle connection is reused.
+	MaxConnectTime Duration `json:"maxConnectTime"`
+	// BodySize sets limits in bytes on the response body size.
+	BodySize BodySize `json:"bodySize"`
+	// TLS sets expectations on the certificate of the server.
+	TLS TLSAssertion `json:"tls"`
+	// FinalURL is the expected URL of the last request, after redirects.
+	FinalURL string `json:"finalURL"`
+	// Redirects lists expectations on each redirect that was followed. The
+	// number of redirects must match.
+	Redirects []RedirectAssertion `json:"redirects"`
+}
+
+// RedirectAssertion describes expectations on a redirect response.
+type RedirectAssertion struct {
+	// StatusCode lists the accepted status codes of the redirect, any when empty.
+	StatusCode StatusCodes `json:"statusCode"`
+	// Location is the expected Location header, as sent or resolved against
+	// the URL of the request.
+	Location string `json:"location"`
+}
+
+// TLSAssertion describes expectations on the certificate of the server.
+type TLSAssertion struct {
+	// MinValidDays fails when the certificate expires within this many days.
+	MinValidDays *int `json:"minValidDays"`
+	// SANs lists DNS names and IP addresses the certificate must be valid for.
+	SANs []string `json:"sans"`
+}
+
+// StatusCodes lists accepted response status codes, such as "200", and
+// classes of status codes, such as "2xx". In a testsuite it is a number, a
+// class or an array of them.
+type StatusCodes []string
+
+// Header value modes of a HeaderAssertion.
+const (
+	// HeaderValuesAny passes when one of the values of the header matches.
+	HeaderValuesAny = "any"
+	// HeaderValuesAll passes when every value of the header matches.
+	HeaderValuesAll = "all"
+)
+
+// HeaderAssertion describes expectations on the values of a response header.
+// Each header line of the response is one value. In a testsuite it is either
+// a string, or an object of checks such as {"matches": "^id=", "values": "all"}.
+type HeaderAssertion struct {
+	// Value is the string form of the assertion. A header value passes when
+	// it equals Value ignoring case, or else when it matches Value as a regex.
+	Value string `json:"-"`
+	// Equals is compared with the header values ignoring case.
+	Equals string `json:"equals"`
+	// Matches is a regex matched on the header values.
+	Matches string `json:"matches"`
+	// Values is any or all, any when empty.
+	Values string `json:"values"`
+	// Count is the expected number of values, zero when the header must not be set.
+	Count *int `json:"count"`
+}
+
+// CookieAssertion describes expectations on a cookie set by the response.
+// Fields that are not set are not checked.
+type CookieAssertion struct {
+	// Exists checks whether the response sets the cookie, true when not set.
+	Exists *bool `json:"exists"`
+	// Value is the expected value of the cookie.
+	Value *string `json:"value"`
+	// Matches is a regex matched on the value of the cookie.
+	Matches  string `json:"matches"`
+	Secure   *bool  `json:"secure"`
+	HTTPOnly *bool  `json:"httpOnly"`
+	// SameSite is strict, lax or none.
+	SameSite string `json:"sameSite"`
+	Path     string `json:"path"`
+	Domain   string `json:"domain"`
+}
+
+// BodySize sets the minimum and maximum size of a response body in bytes.
+type BodySize struct {
+	Min *int64 `json:"min"`
+	Max *int64 `json:"max"`
+}
+
+// JSONAssertion describes expectations on the values found at a JSONPath.
+// In a testsuite it is either a plain value to compare with, or an object of
+// checks such as {"type": "number", "gte": 1}. Every check must pass for
+// every value found at the path.
+type JSONAssertion struct {
+	// Equals is the JSON encoded expected value, nil when not set.
+	Equals json.RawMessage `json:"equals"`
+	// Type is one of string, number, integer, boolean, array, object or null.
+	Type string `json:"type"`
+	// Exists checks whether the path matches any value.
+	Exists *bool `json:"exists"`
+	// Length is the expected length of an array, object or string.
+	Length *int `json:"length"`
+	// Gt, Gte, Lt and Lte compare a number, numbers are compared exactly.
+	Gt  *json.Number `json:"gt"`
+	Gte *json.Number `json:"gte"`
+	Lt  *json.Number `json:"lt"`
+	Lte *json.Number `json:"lte"`
+	// Matches is a regex matched on the value. Non-string values are matched
+	// on their JSON encoding.
+	Matches string `json:"matches"`
+}
+
+// Duration is a time.Duration that is decoded from a duration string such as
+// "300ms" or "1.5s", or from a number of seconds.
+type Duration time.Duration
+
+// Extractor describes where to capture a value from a response.
+// Exactly one of JSON, Regex, Header or Cookie must be set.
+type Extractor struct {
+	// JSON is a JSONPath expression evaluated on the response body.
+	JSON string `json:"json"`
+	// Regex is matched on the response body. The value is the capture group
+	// selected by Group, or the first capture group when Group is 0.
+	Regex string `json:"regex"`
+	Group int    `json:"group"`
+	// Header is the name of a response header.
+	Header string `json:"header"`
+	// Cookie is the name of a cookie set by the response.
+	Cookie string `json:"cookie"`
 }
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status is the outcome of a test case.
+type Status string
+
+// Statuses of a test report.
+const (
+	StatusPassed Status = "passed"
+	StatusFailed Status = "failed"
+	// StatusCancelled is set when the run was stopped while the test case was running.
+	StatusCancelled Status = "cancelled"
+	// StatusSkipped is set when the test case, or a test case it depends on, is marked skip.
+	StatusSkipped Status = "skipped"
+)
+
+// The TestReport holds results of a test case.
+type TestReport struct {
+	Index    int
+	Name     string
+	Status   Status
+	Err      error
+	Duration time.Duration
+	Source   string
+	URL      string
+	Method   string
+	// StatusCode is the response status code, zero when no response was received.
+	StatusCode int
+	// RemoteAddr is the address the request was sent to, the proxy when a
+	// proxy was used. It is empty when no connection was made.
+	RemoteAddr string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Attempts is the number of requests sent for the test case, more than one when it was retried.
+	Attempts int
+	// SkipReason explains why a skipped test case did not run.
+	SkipReason string
+}
+
+// String method returns the test result as string.
+func (r *TestReport) String() string {
+	var attempts string
+	if r.Attempts > 1 {
+		attempts = fmt.Sprintf(" after %d attempts", r.Attempts)
+	}
+
+	var via string
+	if r.RemoteAddr != "" {
+		via = " via " + r.RemoteAddr
+	}
+
+	if r.Skipped() {
+		return fmt.Sprintf("SKIP: testcase #%d \"%s\" %s", r.Index, r.Name, r.SkipReason)
+	}
+
+	if r.Cancelled() {
+		return fmt.Sprintf("CANCEL: testcase #%d \"%s\"%s%s (%.2fs)", r.Index, r.Name, via, attempts, r.Duration.Seconds())
+	}
+
+	if !r.Passed() && r.Source != "" {
+		return fmt.Sprintf("FAIL: testcase #%d \"%s\" in %s%s %s%s (%.2fs)", r.Index, r.Name, r.Source, via, r.Err, attempts, r.Duration.Seconds())
+	}
+
+	if !r.Passed() {
+		return fmt.Sprintf("FAIL: testcase #%d \"%s\"%s %s%s (%.2fs)", r.Index, r.Name, via, r.Err, attempts, r.Duration.Seconds())
+	}
+
+	return fmt.Sprintf("PASS: testcase #%d \"%s\"%s%s (%.2fs)", r.Index, r.Name, via, attempts, r.Duration.Seconds())
+}
+
+// Retried returns true when more than one request was sent for the test case.
+func (r *TestReport) Retried() bool {
+	return r.Attempts > 1
+}
+
+// Passed method checks if test result was successful.
+func (r *TestReport) Passed() bool {
+	return r.Status == StatusPassed
+}
+
+// Cancelled checks if the run was stopped while the test case was running.
+func (r *TestReport) Cancelled() bool {
+	return r.Status == StatusCancelled
+}
+
+// Skipped checks if the test case was skipped.
+func (r *TestReport) Skipped() bool {
+	return r.Status == StatusSkipped
+}
+
+// StatusText returns the status of the test result as "passed", "failed", "cancelled" or "skipped".
+func (r *TestReport) StatusText() string {
+	if r.Status == "" {
+		return string(StatusFailed)
+	}
+
+	return string(r.Status)
+}
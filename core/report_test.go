@@ -0,0 +1,45 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name           string
+		report         *core.TestReport
+		expectedStatus bool
+		expectedString string
+	}{
+		{"passed", &core.TestReport{Index: 1, Name: "a", Status: core.StatusPassed, Duration: time.Duration(1) * time.Second}, true, "PASS: testcase #1 \"a\" (1.00s)"},
+		{"failed", &core.TestReport{Index: 2, Name: "b", Status: core.StatusFailed, Err: errors.New("reason"), Duration: time.Duration(2) * time.Second}, false, "FAIL: testcase #2 \"b\" reason (2.00s)"},
+		{"passed with source", &core.TestReport{Index: 3, Name: "c", Status: core.StatusPassed, Duration: time.Duration(1) * time.Second, Source: "api.json"}, true, "PASS: testcase #3 \"c\" (1.00s)"},
+		{"passed after retrying", &core.TestReport{Index: 5, Name: "e", Status: core.StatusPassed, Duration: time.Duration(3) * time.Second, Attempts: 3}, true, "PASS: testcase #5 \"e\" after 3 attempts (3.00s)"},
+		{"failed after retrying", &core.TestReport{Index: 6, Name: "f", Status: core.StatusFailed, Err: errors.New("reason"), Duration: time.Duration(2) * time.Second, Attempts: 2}, false, "FAIL: testcase #6 \"f\" reason after 2 attempts (2.00s)"},
+		{"cancelled", &core.TestReport{Index: 7, Name: "g", Status: core.StatusCancelled, Err: context.Canceled, Duration: time.Duration(1) * time.Second}, false, "CANCEL: testcase #7 \"g\" (1.00s)"},
+		{"skipped", &core.TestReport{Index: 8, Name: "h", Status: core.StatusSkipped, SkipReason: "endpoint is broken"}, false, "SKIP: testcase #8 \"h\" endpoint is broken"},
+		{"failed with source", &core.TestReport{Index: 4, Name: "d", Status: core.StatusFailed, Err: errors.New("reason"), Duration: time.Duration(2) * time.Second, Source: "api.json"}, false, "FAIL: testcase #4 \"d\" in api.json reason (2.00s)"},
+		{"passed with remote address", &core.TestReport{Index: 9, Name: "i", Status: core.StatusPassed, Duration: time.Duration(1) * time.Second, RemoteAddr: "10.0.0.5:443"}, true, "PASS: testcase #9 \"i\" via 10.0.0.5:443 (1.00s)"},
+		{"failed with remote address", &core.TestReport{Index: 10, Name: "j", Status: core.StatusFailed, Err: errors.New("reason"), Duration: time.Duration(2) * time.Second, Source: "api.json", RemoteAddr: "10.0.0.5:443"}, false, "FAIL: testcase #10 \"j\" in api.json via 10.0.0.5:443 reason (2.00s)"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.report.Passed() != tc.expectedStatus {
+				t.Fatalf("Status does not match\nexpected: %t\nreceived: %t", tc.expectedStatus, tc.report.Passed())
+			}
+
+			if tc.expectedString != tc.report.String() {
+				t.Fatalf("Report string does not match\nexpected: %s\nreceived: %s", tc.expectedString, tc.report.String())
+			}
+		})
+	}
+
+}
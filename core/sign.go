@@ -0,0 +1,18 @@
+package core
+
+// Header returns the header the signature of the request is set in, or an
+// empty string when the request is not signed.
+func (s *Signing) Header() string {
+	switch {
+	case s == nil:
+		return ""
+	case s.SigV4 != nil:
+		return "Authorization"
+	case s.HMAC != nil && s.HMAC.Header != "":
+		return s.HMAC.Header
+	case s.HMAC != nil:
+		return "Authorization"
+	}
+
+	return ""
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ValidStatusCode checks if s is a status code such as 200 or a class of
+// status codes such as 2xx.
+func ValidStatusCode(s string) bool {
+	if len(s) != 3 || s[0] < '1' || s[0] > '5' {
+		return false
+	}
+
+	if strings.EqualFold(s[1:], "xx") {
+		return true
+	}
+
+	_, err := strconv.Atoi(s)
+
+	return err == nil
+}
+
+// Match checks if a status code is one of the accepted codes or classes.
+func (c StatusCodes) Match(code int) bool {
+	s := strconv.Itoa(code)
+
+	for _, accepted := range c {
+		if accepted == s || len(accepted) == 3 && strings.EqualFold(accepted[1:], "xx") && accepted[0] == s[0] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String lists the accepted codes, such as "200, 204 or 3xx".
+func (c StatusCodes) String() string {
+	if len(c) < 2 {
+		return strings.Join(c, "")
+	}
+
+	return strings.Join(c[:len(c)-1], ", ") + " or " + c[len(c)-1]
+}
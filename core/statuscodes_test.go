@@ -0,0 +1,56 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestStatusCodesMatch(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name   string
+		codes  core.StatusCodes
+		code   int
+		expect bool
+	}{
+		{"same code", core.StatusCodes{"200"}, 200, true},
+		{"other code", core.StatusCodes{"200"}, 201, false},
+		{"one of the codes", core.StatusCodes{"200", "204"}, 204, true},
+		{"class", core.StatusCodes{"2xx"}, 201, true},
+		{"class in upper case", core.StatusCodes{"3XX"}, 302, true},
+		{"other class", core.StatusCodes{"2xx"}, 404, false},
+		{"no response", core.StatusCodes{"2xx"}, 0, false},
+		{"empty", core.StatusCodes{}, 200, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if matched := tc.codes.Match(tc.code); matched != tc.expect {
+				t.Fatalf("Match does not match\nexpected: %t\nreceived: %t", tc.expect, matched)
+			}
+		})
+	}
+}
+
+func TestStatusCodesString(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		codes  core.StatusCodes
+		expect string
+	}{
+		{core.StatusCodes{"200"}, "200"},
+		{core.StatusCodes{"200", "2xx"}, "200 or 2xx"},
+		{core.StatusCodes{"200", "204", "3xx"}, "200, 204 or 3xx"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.expect, func(t *testing.T) {
+			if s := tc.codes.String(); s != tc.expect {
+				t.Fatalf("String does not match\nexpected: %s\nreceived: %s", tc.expect, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ValueError is an error decoding a value of a testsuite. Value is the JSON
+// being decoded, loaders use it to report the position of the error. When Err
+// is a *json.UnmarshalTypeError, its offset is relative to Value.
+type ValueError struct {
+	Value []byte
+	Err   error
+}
+
+func (e *ValueError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+// valueError wraps an error decoding data. Errors of the values inside data
+// are returned as they are, they point at a more precise position.
+func valueError(data []byte, err error) error {
+	var valueErr *ValueError
+	if errors.As(err, &valueErr) {
+		return err
+	}
+
+	return &ValueError{Value: data, Err: err}
+}
+
+var jsonAssertionChecks = map[string]bool{
+	"equals": true, "type": true, "exists": true, "length": true,
+	"gt": true, "gte": true, "lt": true, "lte": true, "matches": true,
+}
+
+// UnmarshalJSON decodes an object of checks, or any other value as the
+// expected value of an equality check. Use {"equals": {...}} to compare with
+// an object.
+func (a *JSONAssertion) UnmarshalJSON(data []byte) error {
+	var checks map[string]json.RawMessage
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) && json.Unmarshal(data, &checks) == nil && len(checks) > 0 {
+		isChecks := true
+
+		for name := range checks {
+			if !jsonAssertionChecks[name] {
+				isChecks = false
+				break
+			}
+		}
+
+		if isChecks {
+			type plain JSONAssertion
+
+			if err := json.Unmarshal(data, (*plain)(a)); err != nil {
+				return valueError(data, err)
+			}
+
+			return nil
+		}
+	}
+
+	*a = JSONAssertion{Equals: append(json.RawMessage{}, data...)}
+
+	return nil
+}
+
+// UnmarshalJSON decodes the string form of the assertion, or an object of checks.
+func (a *HeaderAssertion) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*a = HeaderAssertion{Value: value}
+		return nil
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return valueError(data, fmt.Errorf("invalid header assertion %s: must be a string or an object of checks", data))
+	}
+
+	type plain HeaderAssertion
+
+	if err := json.Unmarshal(data, (*plain)(a)); err != nil {
+		return valueError(data, err)
+	}
+
+	return nil
+}
+
+// UnmarshalJSON decodes true, false or a number of redirects.
+func (r *Redirects) UnmarshalJSON(data []byte) error {
+	var follow bool
+	if err := json.Unmarshal(data, &follow); err == nil {
+		*r = 0
+		if follow {
+			*r = DefaultRedirects
+		}
+
+		return nil
+	}
+
+	var max int
+	if err := json.Unmarshal(data, &max); err != nil || max < 0 {
+		return valueError(data, fmt.Errorf("invalid followRedirects %s: must be true, false or a number >= 0", data))
+	}
+
+	*r = Redirects(max)
+
+	return nil
+}
+
+// UnmarshalJSON decodes a duration string such as "300ms" or "1.5s",
+// or a number of seconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return valueError(data, fmt.Errorf("invalid duration %s: must be a string such as \"1.5s\" or a number of seconds", data))
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return valueError(data, fmt.Errorf("invalid duration %q: %w", s, err))
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// UnmarshalJSON decodes a list of outcomes, where status codes can be
+// written as numbers.
+func (o *Outcomes) UnmarshalJSON(data []byte) error {
+	var values []json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return valueError(data, fmt.Errorf("invalid outcomes %s: must be a list such as [\"network\", 503]", data))
+	}
+
+	outcomes := make(Outcomes, 0, len(values))
+
+	for _, value := range values {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			outcomes = append(outcomes, s)
+			continue
+		}
+
+		var code int
+		if err := json.Unmarshal(value, &code); err != nil {
+			return valueError(data, fmt.Errorf("invalid outcome %s: must be a string or a status code", value))
+		}
+
+		outcomes = append(outcomes, strconv.Itoa(code))
+	}
+
+	*o = outcomes
+
+	return nil
+}
+
+// UnmarshalJSON decodes a status code, a class of status codes such as
+// "2xx", or an array of them. 0 is the default status code, as when the
+// status code is not set.
+func (c *StatusCodes) UnmarshalJSON(data []byte) error {
+	var values []json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		values = []json.RawMessage{data}
+	}
+
+	codes := make(StatusCodes, 0, len(values))
+
+	for _, value := range values {
+		var code int
+		if err := json.Unmarshal(value, &code); err == nil {
+			if code == 0 {
+				continue
+			}
+
+			value, _ = json.Marshal(strconv.Itoa(code))
+		}
+
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil || !ValidStatusCode(s) {
+			return valueError(data, fmt.Errorf("invalid status code %s: must be a number such as 200 or a class such as \"2xx\"", value))
+		}
+
+		codes = append(codes, s)
+	}
+
+	if len(codes) == 0 {
+		// The status code is not set, so the default of the testsuite applies.
+		codes = nil
+	}
+
+	*c = codes
+
+	return nil
+}
@@ -0,0 +1,278 @@
+package core_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestJSONAssertionUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	yes := true
+	three := 3
+	one := json.Number("1")
+
+	tt := []struct {
+		name   string
+		input  string
+		expect core.JSONAssertion
+	}{
+		{"string value", `"ok"`, core.JSONAssertion{Equals: json.RawMessage(`"ok"`)}},
+		{"number value", `42`, core.JSONAssertion{Equals: json.RawMessage(`42`)}},
+		{"null value", `null`, core.JSONAssertion{Equals: json.RawMessage(`null`)}},
+		{"array value", `[1, 2]`, core.JSONAssertion{Equals: json.RawMessage(`[1, 2]`)}},
+		{"object value", `{"id": 1}`, core.JSONAssertion{Equals: json.RawMessage(`{"id": 1}`)}},
+		{"checks", `{"type": "array", "exists": true, "length": 3, "gte": 1, "matches": "^a"}`, core.JSONAssertion{Type: "array", Exists: &yes, Length: &three, Gte: &one, Matches: "^a"}},
+		{"equals check with object", `{"equals": {"id": 1}}`, core.JSONAssertion{Equals: json.RawMessage(`{"id": 1}`)}},
+		{"equals check with null", `{"equals": null}`, core.JSONAssertion{Equals: json.RawMessage(`null`)}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var a core.JSONAssertion
+
+			if err := json.Unmarshal([]byte(tc.input), &a); err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			if !reflect.DeepEqual(tc.expect, a) {
+				t.Fatalf("Assertion does not match\nexpected: %+v\nreceived: %+v", tc.expect, a)
+			}
+		})
+	}
+}
+
+func TestDurationUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		input     string
+		expect    core.Duration
+		expectErr string
+	}{
+		{"duration string", `"300ms"`, core.Duration(300 * time.Millisecond), ""},
+		{"seconds", `2`, core.Duration(2 * time.Second), ""},
+		{"fraction of seconds", `0.5`, core.Duration(500 * time.Millisecond), ""},
+		{"invalid string", `"soon"`, 0, "invalid duration \"soon\""},
+		{"invalid type", `true`, 0, "invalid duration true"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var d core.Duration
+
+			err := json.Unmarshal([]byte(tc.input), &d)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if d != tc.expect {
+				t.Fatalf("Duration does not match\nexpected: %s\nreceived: %s", time.Duration(tc.expect), time.Duration(d))
+			}
+		})
+	}
+}
+
+func TestOutcomesUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		input     string
+		expect    core.Outcomes
+		expectErr string
+	}{
+		{"strings", `["network", "5xx"]`, core.Outcomes{"network", "5xx"}, ""},
+		{"status codes", `[502, "503"]`, core.Outcomes{"502", "503"}, ""},
+		{"empty list", `[]`, core.Outcomes{}, ""},
+		{"not a list", `"network"`, nil, "invalid outcomes \"network\""},
+		{"invalid item", `[true]`, nil, "invalid outcome true"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var o core.Outcomes
+
+			err := json.Unmarshal([]byte(tc.input), &o)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if !reflect.DeepEqual(o, tc.expect) {
+				t.Fatalf("Outcomes do not match\nexpected: %v\nreceived: %v", tc.expect, o)
+			}
+		})
+	}
+}
+
+func TestStatusCodesUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		input     string
+		expect    core.StatusCodes
+		expectErr string
+	}{
+		{"number", `201`, core.StatusCodes{"201"}, ""},
+		{"string", `"204"`, core.StatusCodes{"204"}, ""},
+		{"class", `"2xx"`, core.StatusCodes{"2xx"}, ""},
+		{"array", `[200, "204", "3XX"]`, core.StatusCodes{"200", "204", "3XX"}, ""},
+		{"zero is the default", `0`, nil, ""},
+		{"zero in array", `[0, 201]`, core.StatusCodes{"201"}, ""},
+		{"invalid string", `"ok"`, nil, "invalid status code \"ok\""},
+		{"out of range", `600`, nil, "invalid status code \"600\""},
+		{"invalid class", `"2x"`, nil, "invalid status code \"2x\""},
+		{"invalid item", `[200, true]`, nil, "invalid status code true"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var c core.StatusCodes
+
+			err := json.Unmarshal([]byte(tc.input), &c)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if !reflect.DeepEqual(c, tc.expect) {
+				t.Fatalf("Status codes do not match\nexpected: %v\nreceived: %v", tc.expect, c)
+			}
+		})
+	}
+}
+
+func TestHeaderAssertionUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	two := 2
+
+	tt := []struct {
+		name      string
+		input     string
+		expect    core.HeaderAssertion
+		expectErr string
+	}{
+		{"string", `"application/json"`, core.HeaderAssertion{Value: "application/json"}, ""},
+		{"checks", `{"equals": "gzip", "matches": "^g", "values": "all", "count": 2}`, core.HeaderAssertion{Equals: "gzip", Matches: "^g", Values: "all", Count: &two}, ""},
+		{"invalid type", `42`, core.HeaderAssertion{}, "invalid header assertion 42"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var a core.HeaderAssertion
+
+			err := json.Unmarshal([]byte(tc.input), &a)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if !reflect.DeepEqual(a, tc.expect) {
+				t.Fatalf("Assertion does not match\nexpected: %+v\nreceived: %+v", tc.expect, a)
+			}
+		})
+	}
+}
+
+func TestRedirectsUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		input     string
+		expect    core.Redirects
+		expectErr string
+	}{
+		{"true", `true`, core.DefaultRedirects, ""},
+		{"false", `false`, 0, ""},
+		{"number", `3`, 3, ""},
+		{"negative", `-1`, 0, "invalid followRedirects -1"},
+		{"invalid type", `"yes"`, 0, "invalid followRedirects \"yes\""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r core.Redirects
+
+			err := json.Unmarshal([]byte(tc.input), &r)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if r != tc.expect {
+				t.Fatalf("Redirects do not match\nexpected: %d\nreceived: %d", tc.expect, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package core
+
+import "strings"
+
+// ResolveURL prepends the base URL to a URL without a scheme.
+func ResolveURL(baseURL string, url string) string {
+	if baseURL == "" || strings.Contains(url, "://") {
+		return url
+	}
+
+	if url == "" || strings.HasPrefix(url, "?") || strings.HasPrefix(url, "#") {
+		return baseURL + url
+	}
+
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(url, "/")
+}
@@ -2,4 +2,8 @@ module github.com/amad/smoker
 
 go 1.13
 
-require github.com/google/uuid v1.1.1
+require (
+	github.com/google/uuid v1.1.1
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,222 @@
+package loader
+
+import (
+	"encoding/json"
+	"net/textproto"
+
+	"github.com/amad/smoker/core"
+)
+
+// applyDefaults fills the fields a test case does not set from the defaults
+// of its testsuite. Maps are copied, so test cases do not share them.
+func applyDefaults(tc *core.TestCase, suite *core.Testsuite) {
+	d := suite.Defaults
+
+	// The base URL is resolved once variables are expanded, as they can set
+	// the scheme of the URL.
+	tc.BaseURL = d.BaseURL
+
+	if tc.Method == "" {
+		tc.Method = d.Method
+	}
+
+	tc.DefaultTimeout = d.Timeout
+
+	if tc.Timeout == 0 {
+		tc.Timeout = d.Timeout
+	}
+
+	if tc.Session == "" {
+		tc.Session = d.Session
+	}
+
+	if tc.FollowRedirects == nil {
+		tc.FollowRedirects = d.FollowRedirects
+	}
+
+	if tc.Auth == nil {
+		tc.Auth = d.Auth
+	}
+
+	if tc.Sign == nil {
+		tc.Sign = d.Sign
+	}
+
+	tc.Headers = mergeHeaders(tc.Headers, d.Headers)
+	mergeTLS(&tc.TLS, d.TLS)
+	mergeNetwork(&tc.Network, d.Network)
+	mergeAssertions(&tc.Assertions, d.Assertions)
+}
+
+// mergeHeaders adds the default headers a test case does not set. Header
+// names are compared in their canonical form, so content-type overrides
+// Content-Type.
+func mergeHeaders(headers map[string]string, defaults map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return headers
+	}
+
+	merged := make(map[string]string, len(headers)+len(defaults))
+	set := make(map[string]bool, len(headers))
+
+	for name, value := range headers {
+		merged[name] = value
+		set[textproto.CanonicalMIMEHeaderKey(name)] = true
+	}
+
+	for name, value := range defaults {
+		if !set[textproto.CanonicalMIMEHeaderKey(name)] {
+			merged[name] = value
+		}
+	}
+
+	return merged
+}
+
+func mergeTLS(t *core.TLS, d core.TLS) {
+	if t.CA == "" {
+		t.CA = d.CA
+	}
+
+	if t.Cert == "" && t.Key == "" {
+		t.Cert, t.Key = d.Cert, d.Key
+	}
+
+	if t.ServerName == "" {
+		t.ServerName = d.ServerName
+	}
+
+	if t.MinVersion == "" {
+		t.MinVersion = d.MinVersion
+	}
+
+	if t.InsecureSkipVerify == nil {
+		t.InsecureSkipVerify = d.InsecureSkipVerify
+	}
+}
+
+func mergeNetwork(n *core.Network, d core.Network) {
+	if n.Proxy == "" {
+		n.Proxy = d.Proxy
+	}
+
+	if n.Resolve == nil {
+		n.Resolve = d.Resolve
+	}
+
+	if n.ConnectTo == nil {
+		n.ConnectTo = d.ConnectTo
+	}
+}
+
+// mergeHeaderAssertions adds the default header assertions a test case does
+// not set, comparing header names like mergeHeaders.
+func mergeHeaderAssertions(assertions map[string]core.HeaderAssertion, defaults map[string]core.HeaderAssertion) map[string]core.HeaderAssertion {
+	if len(defaults) == 0 {
+		return assertions
+	}
+
+	merged := make(map[string]core.HeaderAssertion, len(assertions)+len(defaults))
+	set := make(map[string]bool, len(assertions))
+
+	for name, a := range assertions {
+		merged[name] = a
+		set[textproto.CanonicalMIMEHeaderKey(name)] = true
+	}
+
+	for name, a := range defaults {
+		if !set[textproto.CanonicalMIMEHeaderKey(name)] {
+			merged[name] = a
+		}
+	}
+
+	return merged
+}
+
+func mergeAssertions(a *core.Assertions, d core.Assertions) {
+	if a.StatusCode == nil {
+		a.StatusCode = d.StatusCode
+	}
+
+	if a.Body == nil {
+		a.Body = d.Body
+	}
+
+	a.Headers = mergeHeaderAssertions(a.Headers, d.Headers)
+
+	if a.BodyNotContains == nil {
+		a.BodyNotContains = d.BodyNotContains
+	}
+
+	if a.HeadersAbsent == nil {
+		a.HeadersAbsent = d.HeadersAbsent
+	}
+
+	if len(d.Cookies) > 0 {
+		merged := make(map[string]core.CookieAssertion, len(a.Cookies)+len(d.Cookies))
+
+		for name, assertion := range d.Cookies {
+			merged[name] = assertion
+		}
+
+		for name, assertion := range a.Cookies {
+			merged[name] = assertion
+		}
+
+		a.Cookies = merged
+	}
+
+	if len(d.JSON) > 0 {
+		merged := make(map[string]core.JSONAssertion, len(a.JSON)+len(d.JSON))
+
+		for path, assertion := range d.JSON {
+			merged[path] = assertion
+		}
+
+		for path, assertion := range a.JSON {
+			merged[path] = assertion
+		}
+
+		a.JSON = merged
+	}
+
+	if a.JSONSchema == nil {
+		a.JSONSchema = append(json.RawMessage(nil), d.JSONSchema...)
+	}
+
+	if a.MaxDuration == 0 {
+		a.MaxDuration = d.MaxDuration
+	}
+
+	if a.MaxTimeToFirstByte == 0 {
+		a.MaxTimeToFirstByte = d.MaxTimeToFirstByte
+	}
+
+	if a.MaxConnectTime == 0 {
+		a.MaxConnectTime = d.MaxConnectTime
+	}
+
+	if a.BodySize.Min == nil {
+		a.BodySize.Min = d.BodySize.Min
+	}
+
+	if a.BodySize.Max == nil {
+		a.BodySize.Max = d.BodySize.Max
+	}
+
+	if a.TLS.MinValidDays == nil {
+		a.TLS.MinValidDays = d.TLS.MinValidDays
+	}
+
+	if a.TLS.SANs == nil {
+		a.TLS.SANs = d.TLS.SANs
+	}
+
+	if a.FinalURL == "" {
+		a.FinalURL = d.FinalURL
+	}
+
+	if a.Redirects == nil {
+		a.Redirects = d.Redirects
+	}
+}
@@ -1,26 +1,139 @@
 package loader
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"strings"
 
 	"github.com/amad/smoker/core"
 )
 
-// LoadTestsuite loads testsuite from a JSON file.
-func LoadTestsuite(filename string) (*core.Testsuite, error) {
-	var testsuite core.Testsuite
+func parseJSON(contents []byte, v interface{}) error {
+	err := json.Unmarshal(contents, v)
 
-	contents, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return &testsuite, fmt.Errorf("unable to open config file: %w", err)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := position(contents, syntaxErr.Offset)
+
+		return &ParseError{Line: line, Column: column, Err: syntaxErr}
+	}
+
+	if start, _, cause, ok := errorSpan(contents, err); ok {
+		line, column := position(contents, start+1)
+
+		return &ParseError{Line: line, Column: column, Err: cause}
+	}
+
+	return err
+}
+
+// errorSpan returns the offsets of the JSON value a decoding error is about
+// and the error to report. Errors of custom unmarshalers hold the value they
+// were decoding, and type errors inside it have offsets relative to it.
+func errorSpan(contents []byte, err error) (int64, int64, error, bool) {
+	base, data := int64(0), contents
+
+	var valueErr *core.ValueError
+	if errors.As(err, &valueErr) {
+		offset, ok := offsetOf(contents, valueErr.Value)
+		if !ok {
+			return 0, 0, err, false
+		}
+
+		base, data, err = offset, valueErr.Value, valueErr.Err
 	}
 
-	err = json.Unmarshal(contents, &testsuite)
-	if err != nil {
-		return &testsuite, fmt.Errorf("unable to parse config file: %w", err)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return base + valueStart(data, typeErr.Offset), base + typeErr.Offset, typeError(typeErr), true
 	}
 
-	return &testsuite, nil
+	if valueErr != nil {
+		return base, base + int64(len(data)), err, true
+	}
+
+	return 0, 0, err, false
+}
+
+// offsetOf returns the offset of value in contents. encoding/json passes
+// custom unmarshalers a slice of its input, so the offset is the difference
+// of their capacities. It falls back to the first equal value.
+func offsetOf(contents []byte, value []byte) (int64, bool) {
+	offset := cap(contents) - cap(value)
+	if offset >= 0 && offset+len(value) <= len(contents) && bytes.Equal(contents[offset:offset+len(value)], value) {
+		return int64(offset), true
+	}
+
+	if i := bytes.Index(contents, value); i >= 0 {
+		return int64(i), true
+	}
+
+	return 0, false
+}
+
+// valueStart returns the offset where the JSON value ending at the given
+// offset begins, so type errors point at the start of the offending value.
+func valueStart(contents []byte, end int64) int64 {
+	dec := json.NewDecoder(bytes.NewReader(contents))
+
+	var open []int64
+
+	for {
+		start := dec.InputOffset()
+
+		tok, err := dec.Token()
+		if err != nil {
+			return end - 1
+		}
+
+		for start < int64(len(contents)) && strings.IndexByte(" \t\r\n,:", contents[start]) >= 0 {
+			start++
+		}
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			// Type errors of objects and arrays end after the opening delimiter.
+			if dec.InputOffset() >= end {
+				return start
+			}
+
+			open = append(open, start)
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			start = open[len(open)-1]
+			open = open[:len(open)-1]
+		}
+
+		if dec.InputOffset() >= end {
+			return start
+		}
+	}
+}
+
+func typeError(e *json.UnmarshalTypeError) error {
+	if e.Field == "" {
+		return fmt.Errorf("cannot use %s as %s", e.Value, e.Type)
+	}
+
+	return fmt.Errorf("cannot use %s as %s in field %q", e.Value, e.Type, e.Field)
+}
+
+// position converts a byte offset reported by encoding/json into
+// a line and a column number, both starting at 1.
+func position(contents []byte, offset int64) (int, int) {
+	if offset > int64(len(contents)) {
+		offset = int64(len(contents))
+	}
+
+	if offset > 0 {
+		offset--
+	}
+
+	before := contents[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+
+	return line, column
 }
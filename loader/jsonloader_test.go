@@ -9,7 +9,6 @@ import (
 	"github.com/amad/smoker/loader"
 )
 
-// LoadTestsuite loads testsuite from a JSON file
 func TestLoadTestsuite(t *testing.T) {
 	t.Parallel()
 
@@ -22,11 +21,14 @@ func TestLoadTestsuite(t *testing.T) {
 		{"load a testsuite", "./testdata/suite1.json", &core.Testsuite{Tests: []core.TestCase{{Name: "test case 1", URL: "https://github.com/amad/smoker"}}}, ""},
 		{"should error on invalid file type", "./testdata/textfile", &core.Testsuite{}, "unable to parse config file"},
 		{"should error on wrong path", "./testdata/notfound.json", &core.Testsuite{}, "unable to open config file"},
+		{"should report position of type errors", "./testdata/badtype.json", &core.Testsuite{}, "line 5, column 18: cannot use string as int"},
+		{"should report position of nested type errors", "./testdata/nestedtype.json", &core.Testsuite{}, "line 8, column 19: cannot use string \"abc\" as json.Number"},
+		{"should report position of invalid values", "./testdata/badvalue.json", &core.Testsuite{}, "line 6, column 18: invalid duration \"abc\""},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := loader.LoadTestsuite(tc.filename)
+			res, err := loader.LoadTestsuite(tc.filename, "")
 
 			if err != nil {
 				if tc.expectErr == "" {
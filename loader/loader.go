@@ -0,0 +1,328 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/vars"
+)
+
+// Supported testsuite file formats.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// LoadTestsuites loads and merges testsuites from files, directories and glob
+// patterns. Directories are walked recursively for JSON and YAML files.
+// Each test case records the file it was loaded from, and the defaults of its
+// testsuite file are merged into it, so test cases are fully resolved.
+//
+// ${NAME} references in test cases are replaced with values from variables,
+// then environment variables, then the variables block of the testsuite file.
+// References to values extracted by a test case are kept, so the runner can
+// replace them once the value is captured: the fields of test cases are
+// templates, where other text reading as a reference is escaped as $${.
+func LoadTestsuites(paths []string, format string, variables map[string]string) (*core.Testsuite, error) {
+	var testsuite core.Testsuite
+
+	files, err := ExpandPaths(paths)
+	if err != nil {
+		return &testsuite, err
+	}
+
+	suites := make([]*core.Testsuite, len(files))
+	extracted := make(map[string]bool)
+
+	for i, filename := range files {
+		suites[i], err = LoadTestsuite(filename, format)
+		if err != nil {
+			return &testsuite, fmt.Errorf("%s: %w", filename, err)
+		}
+
+		for _, tc := range suites[i].Tests {
+			for name := range tc.Extract {
+				extracted[name] = true
+			}
+		}
+	}
+
+	for i, filename := range files {
+		lookup := vars.Chain(vars.Map(variables), os.LookupEnv, vars.Map(suites[i].Variables))
+
+		for _, tc := range suites[i].Tests {
+			applyDefaults(&tc, suites[i])
+
+			if err := vars.TemplateTestCase(&tc, lookup, extracted); err != nil {
+				return &testsuite, fmt.Errorf("%s: testcase %q: %w", filename, tc.Name, err)
+			}
+
+			// A URL starting with a captured value is resolved by the runner.
+			if !strings.HasPrefix(tc.URL, "${") {
+				tc.URL = core.ResolveURL(tc.BaseURL, tc.URL)
+				tc.BaseURL = ""
+			}
+
+			if err := checkSigning(tc); err != nil {
+				return &testsuite, fmt.Errorf("%s: testcase %q: %w", filename, tc.Name, err)
+			}
+
+			if err := resolveJSONSchema(&tc, filename); err != nil {
+				return &testsuite, fmt.Errorf("%s: testcase %q: %w", filename, tc.Name, err)
+			}
+
+			resolveTLSPaths(&tc.TLS, filename)
+			tc.Auth = resolveAuthPaths(tc.Auth, filename)
+
+			tc.Source = filename
+			testsuite.Tests = append(testsuite.Tests, tc)
+		}
+	}
+
+	return &testsuite, nil
+}
+
+// checkSigning returns an error when the signature of a test case replaces
+// the Authorization header set by its auth or its headers.
+func checkSigning(tc core.TestCase) error {
+	if !strings.EqualFold(tc.Sign.Header(), "Authorization") {
+		return nil
+	}
+
+	if tc.Auth != nil && (tc.Auth.Basic != nil || tc.Auth.Bearer != nil || tc.Auth.OAuth2 != nil) {
+		return errors.New("sign sets the Authorization header, which auth also sets")
+	}
+
+	for name := range tc.Headers {
+		if strings.EqualFold(name, "Authorization") {
+			return errors.New("sign sets the Authorization header, which headers also set")
+		}
+	}
+
+	return nil
+}
+
+// resolveJSONSchema replaces a JSON schema path with the contents of the
+// schema file. The path is relative to the testsuite file.
+func resolveJSONSchema(tc *core.TestCase, filename string) error {
+	var schemaFile string
+	if json.Unmarshal(tc.Assertions.JSONSchema, &schemaFile) != nil {
+		return nil
+	}
+
+	if !filepath.IsAbs(schemaFile) {
+		schemaFile = filepath.Join(filepath.Dir(filename), schemaFile)
+	}
+
+	contents, err := ioutil.ReadFile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("unable to open json schema file: %w", err)
+	}
+
+	var schema json.RawMessage
+
+	if DetectFormat(schemaFile) == FormatYAML {
+		err = parseYAML(contents, &schema)
+	} else {
+		err = parseJSON(contents, &schema)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to parse json schema file %s: %w", schemaFile, err)
+	}
+
+	tc.Assertions.JSONSchema = schema
+
+	return nil
+}
+
+// resolveTLSPaths makes the certificate paths of a TLS configuration
+// relative to the testsuite file.
+func resolveTLSPaths(t *core.TLS, filename string) {
+	for _, path := range []*string{&t.CA, &t.Cert, &t.Key} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(filepath.Dir(filename), *path)
+		}
+	}
+}
+
+// resolveAuthPaths makes the bearer token file of an auth block relative to
+// the testsuite file. The block is copied, as test cases share the auth of
+// the testsuite defaults.
+func resolveAuthPaths(auth *core.Auth, filename string) *core.Auth {
+	if auth == nil || auth.Bearer == nil || auth.Bearer.File == "" || filepath.IsAbs(auth.Bearer.File) {
+		return auth
+	}
+
+	resolved := *auth
+	bearer := *auth.Bearer
+	bearer.File = filepath.Join(filepath.Dir(filename), bearer.File)
+	resolved.Bearer = &bearer
+
+	return &resolved
+}
+
+// ExpandPaths resolves files, directories and glob patterns into a list of
+// testsuite files. Files found in the same directory or pattern are sorted by
+// name and a file matched more than once is only returned once.
+func ExpandPaths(paths []string) ([]string, error) {
+	var files []string
+
+	seen := make(map[string]bool)
+
+	add := func(filename string) {
+		if !seen[filepath.Clean(filename)] {
+			seen[filepath.Clean(filename)] = true
+			files = append(files, filename)
+		}
+	}
+
+	for _, path := range paths {
+		matches := []string{path}
+
+		if strings.ContainsAny(path, "*?[") {
+			var err error
+
+			matches, err = filepath.Glob(path)
+			if err != nil {
+				return nil, fmt.Errorf("invalid testsuite pattern %q: %w", path, err)
+			}
+
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no testsuite file matches pattern %q", path)
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("unable to open config file: %w", err)
+			}
+
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+
+			found, err := findTestsuiteFiles(match)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(found) == 0 {
+				return nil, fmt.Errorf("no testsuite file found in directory %q", match)
+			}
+
+			for _, filename := range found {
+				add(filename)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+func findTestsuiteFiles(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json", ".yaml", ".yml":
+			files = append(files, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read directory %q: %w", dir, err)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// LoadTestsuite loads testsuite from a JSON or YAML file.
+// When format is empty, it is picked by the file extension and falls back to JSON.
+func LoadTestsuite(filename string, format string) (*core.Testsuite, error) {
+	var testsuite core.Testsuite
+
+	if format == "" {
+		format = DetectFormat(filename)
+	}
+
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return &testsuite, fmt.Errorf("unable to open config file: %w", err)
+	}
+
+	switch format {
+	case FormatJSON:
+		err = parseJSON(contents, &testsuite)
+	case FormatYAML:
+		err = parseYAML(contents, &testsuite)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+
+	if err != nil {
+		return &testsuite, fmt.Errorf("unable to parse config file: %w", err)
+	}
+
+	// The top-level timeout is an alias of defaults.timeout.
+	if testsuite.Timeout != 0 {
+		if testsuite.Defaults.Timeout != 0 {
+			return &testsuite, errors.New("set only one of timeout or defaults.timeout")
+		}
+
+		testsuite.Defaults.Timeout = testsuite.Timeout
+		testsuite.Timeout = 0
+	}
+
+	return &testsuite, nil
+}
+
+// DetectFormat returns the testsuite format based on the file extension.
+func DetectFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatJSON
+	}
+}
+
+// ParseError describes a syntax or type error at a position of a testsuite file.
+// Column is zero when the parser does not provide it.
+type ParseError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
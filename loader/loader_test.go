@@ -0,0 +1,296 @@
+package loader_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/loader"
+)
+
+func TestLoadTestsuites(t *testing.T) {
+	t.Parallel()
+
+	apiTest := core.TestCase{Name: "api test case", URL: "https://api.github.com", Source: "testdata/suites/api.json"}
+	webTest1 := core.TestCase{Name: "web test case 1", URL: "https://github.com", Source: "testdata/suites/web/web.yaml"}
+	webTest2 := core.TestCase{Name: "web test case 2", URL: "https://github.com/about", Source: "testdata/suites/web/web.yaml"}
+	suite1Test := core.TestCase{Name: "test case 1", URL: "https://github.com/amad/smoker", Source: "testdata/suite1.json"}
+
+	tt := []struct {
+		name      string
+		paths     []string
+		expectRes *core.Testsuite
+		expectErr string
+	}{
+		{"load a single file", []string{"testdata/suite1.json"}, &core.Testsuite{Tests: []core.TestCase{suite1Test}}, ""},
+		{"merge multiple files in order", []string{"testdata/suites/web/web.yaml", "testdata/suite1.json"}, &core.Testsuite{Tests: []core.TestCase{webTest1, webTest2, suite1Test}}, ""},
+		{"walk directories", []string{"testdata/suites"}, &core.Testsuite{Tests: []core.TestCase{apiTest, webTest1, webTest2}}, ""},
+		{"expand glob patterns", []string{"testdata/suites/*/*.yaml", "testdata/suite*.json"}, &core.Testsuite{Tests: []core.TestCase{webTest1, webTest2, suite1Test}}, ""},
+		{"load a file only once", []string{"testdata/suites", "testdata/suites/api.json"}, &core.Testsuite{Tests: []core.TestCase{apiTest, webTest1, webTest2}}, ""},
+		{"should error when pattern matches nothing", []string{"testdata/*.toml"}, &core.Testsuite{}, "no testsuite file matches pattern \"testdata/*.toml\""},
+		{"should error on wrong path", []string{"testdata/suite1.json", "testdata/notfound.json"}, &core.Testsuite{}, "unable to open config file"},
+		{"should name the file that fails to parse", []string{"testdata/suite1.json", "testdata/badtype.json"}, &core.Testsuite{}, "testdata/badtype.json: unable to parse config file"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := loader.LoadTestsuites(tc.paths, "", nil)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if !reflect.DeepEqual(tc.expectRes, res) {
+				t.Fatalf("Response does not match\nexpected: %+v\nreceived: %+v", *tc.expectRes, *res)
+			}
+		})
+	}
+}
+
+func TestLoadTestsuitesWithVariables(t *testing.T) {
+	os.Setenv("SMOKER_TEST_TOKEN", "env-token")
+	defer os.Unsetenv("SMOKER_TEST_TOKEN")
+
+	res, err := loader.LoadTestsuites([]string{"testdata/variables/suite.yaml"}, "", map[string]string{"user": "amad"})
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	expected := core.TestCase{
+		Name:    "uses variables",
+		URL:     "https://github.com/amad",
+		Headers: map[string]string{"Authorization": "token env-token"},
+		// Test cases are templates, the runner turns the escape into ${literal}.
+		Body:   "$${literal}",
+		Source: "testdata/variables/suite.yaml",
+	}
+
+	if !reflect.DeepEqual(expected, res.Tests[0]) {
+		t.Fatalf("Test case does not match\nexpected: %+v\nreceived: %+v", expected, res.Tests[0])
+	}
+
+	_, err = loader.LoadTestsuites([]string{"testdata/undefined.json"}, "", nil)
+
+	expectErr := "testdata/undefined.json: testcase \"uses undefined variable\": undefined variable \"SMOKER_UNDEFINED_HOST\" in url"
+	if err == nil || err.Error() != expectErr {
+		t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %v", expectErr, err)
+	}
+}
+
+func TestLoadTestsuitesWithJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	res, err := loader.LoadTestsuites([]string{"testdata/schema/suite.json"}, "", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	expected := []string{
+		`{"type": "object", "required": ["login"]}`,
+		`{"type":"object","required":["login"]}`,
+		`{"type": "object"}`,
+	}
+
+	for i, tc := range res.Tests {
+		if string(tc.Assertions.JSONSchema) != expected[i] {
+			t.Fatalf("Schema of %q does not match\nexpected: %s\nreceived: %s", tc.Name, expected[i], tc.Assertions.JSONSchema)
+		}
+	}
+
+	_, err = loader.LoadTestsuites([]string{"testdata/schema/missing.json"}, "", nil)
+
+	expectErr := "testdata/schema/missing.json: testcase \"missing schema file\": unable to open json schema file"
+	if err == nil || !strings.Contains(err.Error(), expectErr) {
+		t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %v", expectErr, err)
+	}
+}
+
+func TestLoadTestsuitesWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	res, err := loader.LoadTestsuites([]string{"testdata/timeout/suite.yaml", "testdata/suite1.json"}, "", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	expected := []core.Duration{core.Duration(30 * time.Second), core.Duration(time.Minute), 0}
+
+	for i, tc := range res.Tests {
+		if tc.Timeout != expected[i] {
+			t.Fatalf("Timeout of testcase %q does not match\nexpected: %s\nreceived: %s", tc.Name, time.Duration(expected[i]), time.Duration(tc.Timeout))
+		}
+	}
+
+	_, err = loader.LoadTestsuites([]string{"testdata/timeout/both.yaml"}, "", nil)
+
+	expectErr := "testdata/timeout/both.yaml: set only one of timeout or defaults.timeout"
+	if err == nil || err.Error() != expectErr {
+		t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %v", expectErr, err)
+	}
+}
+
+func TestLoadTestsuitesWithSigning(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		path      string
+		expectErr string
+	}{
+		{
+			name: "signature in another header",
+			path: "testdata/sign/header.yaml",
+		},
+		{
+			name:      "signature replaces auth",
+			path:      "testdata/sign/auth.yaml",
+			expectErr: `testdata/sign/auth.yaml: testcase "signs with sigv4": sign sets the Authorization header, which auth also sets`,
+		},
+		{
+			name:      "signature replaces the authorization header",
+			path:      "testdata/sign/headers.yaml",
+			expectErr: `testdata/sign/headers.yaml: testcase "signs with hmac": sign sets the Authorization header, which headers also set`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := loader.LoadTestsuites([]string{tc.path}, "", nil)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if err.Error() != tc.expectErr {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestLoadTestsuitesWithDefaults(t *testing.T) {
+	t.Parallel()
+
+	res, err := loader.LoadTestsuites([]string{"testdata/defaults/suite.yaml"}, "", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	yes := true
+	expected := []core.TestCase{
+		{
+			Name:           "uses the defaults",
+			URL:            "https://api.example.com/v1/users",
+			Method:         "post",
+			Timeout:        core.Duration(5 * time.Second),
+			DefaultTimeout: core.Duration(5 * time.Second),
+			Session:        "web",
+			Network:        core.Network{Proxy: "http://proxy.internal:3128"},
+			Auth:           &core.Auth{Bearer: &core.BearerAuth{File: filepath.Join("testdata", "defaults", "secrets", "token")}},
+			Sign:           &core.Signing{HMAC: &core.HMACSigning{Secret: "secret", Template: "{method} {path}", Header: "X-Signature"}},
+			TLS:            core.TLS{CA: filepath.Join("testdata", "defaults", "certs", "ca.pem"), MinVersion: "1.2"},
+			Headers:        map[string]string{"Authorization": "Bearer secret", "Content-Type": "application/json"},
+			Assertions: core.Assertions{
+				StatusCode:    core.StatusCodes{"201"},
+				Headers:       map[string]core.HeaderAssertion{"Content-Type": {Value: "application/json"}},
+				HeadersAbsent: []string{"X-Debug-Token"},
+				JSON:          map[string]core.JSONAssertion{"$.ok": {Equals: json.RawMessage("true")}},
+				MaxDuration:   core.Duration(time.Second),
+			},
+			Source: "testdata/defaults/suite.yaml",
+		},
+		{
+			Name:           "overrides the defaults",
+			URL:            "https://example.com/health",
+			Method:         "get",
+			Timeout:        core.Duration(30 * time.Second),
+			DefaultTimeout: core.Duration(5 * time.Second),
+			Session:        "admin",
+			Auth:           &core.Auth{Basic: &core.BasicAuth{Username: "admin", Password: "secret"}},
+			Sign:           &core.Signing{},
+			Network:        core.Network{Proxy: "http://proxy.internal:3128", Resolve: []string{"example.com:443:10.0.0.5"}},
+			TLS:            core.TLS{CA: filepath.Join("testdata", "defaults", "certs", "ca.pem"), ServerName: "internal.example.com", MinVersion: "1.2", InsecureSkipVerify: &yes},
+			Headers:        map[string]string{"Authorization": "Bearer secret", "content-type": "text/plain"},
+			Assertions: core.Assertions{
+				StatusCode:    core.StatusCodes{"200"},
+				Headers:       map[string]core.HeaderAssertion{"Content-Type": {Value: "application/json"}},
+				HeadersAbsent: []string{},
+				JSON:          map[string]core.JSONAssertion{"$.ok": {Equals: json.RawMessage("true")}, "$.status": {Equals: json.RawMessage(`"up"`)}},
+				MaxDuration:   core.Duration(time.Second),
+			},
+			Source: "testdata/defaults/suite.yaml",
+		},
+		{
+			Name:           "uses the base url",
+			URL:            "https://api.example.com/v1/",
+			Method:         "post",
+			Timeout:        core.Duration(5 * time.Second),
+			DefaultTimeout: core.Duration(5 * time.Second),
+			Session:        "web",
+			Network:        core.Network{Proxy: "http://proxy.internal:3128"},
+			Auth:           &core.Auth{Bearer: &core.BearerAuth{File: filepath.Join("testdata", "defaults", "secrets", "token")}},
+			Sign:           &core.Signing{HMAC: &core.HMACSigning{Secret: "secret", Template: "{method} {path}", Header: "X-Signature"}},
+			TLS:            core.TLS{CA: filepath.Join("testdata", "defaults", "certs", "ca.pem"), MinVersion: "1.2"},
+			Headers:        map[string]string{"Authorization": "Bearer secret", "Content-Type": "application/json"},
+			Assertions: core.Assertions{
+				StatusCode:    core.StatusCodes{"201"},
+				Headers:       map[string]core.HeaderAssertion{"Content-Type": {Value: "application/json"}},
+				HeadersAbsent: []string{"X-Debug-Token"},
+				JSON:          map[string]core.JSONAssertion{"$.ok": {Equals: json.RawMessage("true")}},
+				MaxDuration:   core.Duration(time.Second),
+			},
+			Source: "testdata/defaults/suite.yaml",
+		},
+	}
+
+	if !reflect.DeepEqual(expected, res.Tests) {
+		t.Fatalf("Test cases do not match\nexpected: %+v\nreceived: %+v", expected, res.Tests)
+	}
+}
+
+func TestLoadTestsuitesWithBaseURL(t *testing.T) {
+	t.Parallel()
+
+	res, err := loader.LoadTestsuites([]string{"testdata/defaults/urls.yaml"}, "", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	// URLs starting with a captured value keep the base URL for the runner.
+	expected := [][2]string{
+		{"https://api.example.com/v1/orders", ""},
+		{"https://other.example.com/health", ""},
+		{"${next_link}", "https://api.example.com/v1"},
+	}
+
+	for i, tc := range res.Tests {
+		if tc.URL != expected[i][0] || tc.BaseURL != expected[i][1] {
+			t.Fatalf("URL of testcase %q does not match\nexpected: %s (base %q)\nreceived: %s (base %q)", tc.Name, expected[i][0], expected[i][1], tc.URL, tc.BaseURL)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseYAML converts a YAML document into JSON and decodes it with
+// encoding/json, so YAML and JSON testsuites share the same field names
+// and decoding rules. Positions of the converted values are kept to report
+// decoding errors at the right line and column of the YAML source.
+// Unquoted numbers and booleans decoded into strings keep their YAML text.
+func parseYAML(contents []byte, v interface{}) error {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(contents, &doc); err != nil {
+		return yamlSyntaxError(err)
+	}
+
+	c := &yamlConverter{}
+	if err := c.convert(&doc, reflect.TypeOf(v)); err != nil {
+		return err
+	}
+
+	converted := c.buf.Bytes()
+
+	err := json.Unmarshal(converted, v)
+	if err == nil {
+		return nil
+	}
+
+	start, end, cause, ok := errorSpan(converted, err)
+	if !ok {
+		return err
+	}
+
+	if node := c.nodeAt(start, end); node != nil {
+		return &ParseError{Line: node.Line, Column: node.Column, Err: cause}
+	}
+
+	return cause
+}
+
+var yamlLineRegexp = regexp.MustCompile(`^yaml: line ([0-9]+): (.*)$`)
+
+// yamlSyntaxError extracts the line number from the YAML parser error.
+// The parser does not expose the column of syntax errors.
+func yamlSyntaxError(err error) error {
+	matches := yamlLineRegexp.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return err
+	}
+
+	line, _ := strconv.Atoi(matches[1])
+
+	return &ParseError{Line: line, Err: errors.New(matches[2])}
+}
+
+type yamlSpan struct {
+	start, end int
+	node       *yaml.Node
+}
+
+// maxAliases limits how many aliases a document can expand, so a small
+// file can not expand into a huge testsuite.
+const maxAliases = 10000
+
+type yamlConverter struct {
+	buf   bytes.Buffer
+	spans []yamlSpan
+	// converting holds the nodes being converted, an alias to one of them is
+	// recursive.
+	converting map[*yaml.Node]bool
+	aliases    int
+}
+
+// nodeAt returns the innermost YAML node that was converted into JSON
+// containing the given offsets.
+func (c *yamlConverter) nodeAt(start, end int64) *yaml.Node {
+	var found *yamlSpan
+
+	for i, s := range c.spans {
+		if int64(s.start) > start || int64(s.end) < end {
+			continue
+		}
+
+		if found == nil || s.end-s.start < found.end-found.start {
+			found = &c.spans[i]
+		}
+	}
+
+	if found == nil {
+		return nil
+	}
+
+	return found.node
+}
+
+// convert writes the JSON of a node. t is the type the node is decoded
+// into, nil when it is not known.
+func (c *yamlConverter) convert(node *yaml.Node, t reflect.Type) error {
+	start := c.buf.Len()
+	t = targetType(t)
+
+	if c.converting == nil {
+		c.converting = make(map[*yaml.Node]bool)
+	}
+
+	c.converting[node] = true
+	defer delete(c.converting, node)
+
+	var err error
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			c.buf.WriteString("null")
+			return nil
+		}
+
+		return c.convert(node.Content[0], t)
+	case yaml.AliasNode:
+		target, err := c.resolve(node)
+		if err != nil {
+			return err
+		}
+
+		return c.convert(target, t)
+	case yaml.MappingNode:
+		err = c.convertMapping(node, t)
+	case yaml.SequenceNode:
+		err = c.convertSequence(node, t)
+	case yaml.ScalarNode:
+		err = c.convertScalar(node, t)
+	default:
+		c.buf.WriteString("null")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.spans = append(c.spans, yamlSpan{start, c.buf.Len(), node})
+
+	return nil
+}
+
+func (c *yamlConverter) convertMapping(node *yaml.Node, t reflect.Type) error {
+	c.buf.WriteByte('{')
+
+	pairs, err := c.mappingPairs(node)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		key, value := pairs[i], pairs[i+1]
+
+		if key.Kind != yaml.ScalarNode {
+			return &ParseError{Line: key.Line, Column: key.Column, Err: errors.New("mapping keys must be scalar values")}
+		}
+
+		if i > 0 {
+			c.buf.WriteByte(',')
+		}
+
+		name, _ := json.Marshal(key.Value)
+		c.buf.Write(name)
+		c.buf.WriteByte(':')
+
+		var valueType reflect.Type
+
+		switch {
+		case t == nil:
+		case t.Kind() == reflect.Struct:
+			valueType = fieldType(t, key.Value)
+		case t.Kind() == reflect.Map:
+			valueType = t.Elem()
+		}
+
+		if err := c.convert(value, valueType); err != nil {
+			return err
+		}
+	}
+
+	c.buf.WriteByte('}')
+
+	return nil
+}
+
+// mappingPairs flattens the key and value nodes of a mapping and resolves
+// merge keys (<<). Merged pairs come first, so explicit keys override them.
+func (c *yamlConverter) mappingPairs(node *yaml.Node) ([]*yaml.Node, error) {
+	var merged, own []*yaml.Node
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if key.Kind != yaml.ScalarNode || key.ShortTag() != "!!merge" {
+			own = append(own, key, value)
+			continue
+		}
+
+		value, err := c.resolve(value)
+		if err != nil {
+			return nil, err
+		}
+
+		sources := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+
+		for _, source := range sources {
+			if source, err = c.resolve(source); err != nil {
+				return nil, err
+			}
+
+			if source.Kind != yaml.MappingNode {
+				continue
+			}
+
+			c.converting[source] = true
+			pairs, err := c.mappingPairs(source)
+			delete(c.converting, source)
+
+			if err != nil {
+				return nil, err
+			}
+
+			merged = append(merged, pairs...)
+		}
+	}
+
+	return append(merged, own...), nil
+}
+
+// resolve returns the node an alias refers to. It fails on aliases to a node
+// being converted, which would expand forever, and when the document expands
+// too many aliases.
+func (c *yamlConverter) resolve(node *yaml.Node) (*yaml.Node, error) {
+	for node.Kind == yaml.AliasNode {
+		if c.converting[node.Alias] {
+			return nil, &ParseError{Line: node.Line, Column: node.Column, Err: fmt.Errorf("alias *%s refers to a value containing it", node.Value)}
+		}
+
+		c.aliases++
+		if c.aliases > maxAliases {
+			return nil, &ParseError{Line: node.Line, Column: node.Column, Err: fmt.Errorf("too many aliases, a document can expand at most %d", maxAliases)}
+		}
+
+		node = node.Alias
+	}
+
+	return node, nil
+}
+
+func (c *yamlConverter) convertSequence(node *yaml.Node, t reflect.Type) error {
+	c.buf.WriteByte('[')
+
+	var itemType reflect.Type
+	if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		itemType = t.Elem()
+	}
+
+	for i, item := range node.Content {
+		if i > 0 {
+			c.buf.WriteByte(',')
+		}
+
+		if err := c.convert(item, itemType); err != nil {
+			return err
+		}
+	}
+
+	c.buf.WriteByte(']')
+
+	return nil
+}
+
+func (c *yamlConverter) convertScalar(node *yaml.Node, t reflect.Type) error {
+	var value interface{}
+
+	switch node.ShortTag() {
+	case "!!null":
+		value = nil
+	case "!!bool", "!!int", "!!float":
+		if t != nil && t.Kind() == reflect.String && t != numberType {
+			value = node.Value
+			break
+		}
+
+		if err := node.Decode(&value); err != nil {
+			return &ParseError{Line: node.Line, Column: node.Column, Err: err}
+		}
+	default:
+		value = node.Value
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return &ParseError{Line: node.Line, Column: node.Column, Err: fmt.Errorf("unsupported value %q", node.Value)}
+	}
+
+	c.buf.Write(encoded)
+
+	return nil
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	numberType      = reflect.TypeOf(json.Number(""))
+)
+
+// targetType returns the type a value is decoded into, dereferencing
+// pointers. Types with their own decoding accept any value, so they are not
+// known.
+func targetType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	return t
+}
+
+// fieldType returns the type of the struct field a key is decoded into,
+// following the field names of encoding/json. It returns nil when there is
+// no such field.
+func fieldType(t reflect.Type, key string) reflect.Type {
+	var folded reflect.Type
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+
+		if name == "" && f.Anonymous {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				if found := fieldType(embedded, key); found != nil {
+					return found
+				}
+
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		if name == key {
+			return f.Type
+		}
+
+		if folded == nil && strings.EqualFold(name, key) {
+			folded = f.Type
+		}
+	}
+
+	return folded
+}
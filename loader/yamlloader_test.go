@@ -0,0 +1,109 @@
+package loader_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/loader"
+)
+
+func TestLoadYAMLTestsuite(t *testing.T) {
+	t.Parallel()
+
+	retries, three := 5, 3
+	retryDelay := core.Duration(500 * time.Millisecond)
+
+	tt := []struct {
+		name      string
+		filename  string
+		format    string
+		expectRes *core.Testsuite
+		expectErr string
+	}{
+		{"load a testsuite", "./testdata/suite1.yaml", "", &core.Testsuite{Tests: []core.TestCase{{Name: "test case 1", URL: "https://github.com/amad/smoker"}}}, ""},
+		{
+			"load a testsuite with anchors and merge keys",
+			"./testdata/suite2.yml",
+			"",
+			&core.Testsuite{Tests: []core.TestCase{{
+				Name:       "test case 1",
+				URL:        "https://github.com/amad/smoker",
+				Method:     "post",
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Body:       `{"test":1}`,
+				Assertions: core.Assertions{StatusCode: core.StatusCodes{"201"}, Body: []string{`"id":\s*[0-9]+`}},
+			}}},
+			"",
+		},
+		{
+			"load retry options",
+			"./testdata/retry.yaml",
+			"",
+			&core.Testsuite{Tests: []core.TestCase{{
+				Name:        "flaky after deploy",
+				URL:         "https://github.com/amad/smoker",
+				RetryPolicy: core.RetryPolicy{Retries: &retries, RetryDelay: &retryDelay, Backoff: "exponential-jitter", RetryOn: []string{"network", "503"}},
+			}}},
+			"",
+		},
+		{
+			"load unquoted numbers and booleans into strings",
+			"./testdata/scalars.yaml",
+			"",
+			&core.Testsuite{
+				Variables: map[string]string{"port": "8080", "enabled": "true", "ratio": "1.50"},
+				Tests: []core.TestCase{{
+					Name:        "unquoted scalars",
+					URL:         "https://github.com/amad/smoker",
+					Headers:     map[string]string{"Accept-Version": "2", "X-Debug": "false"},
+					Body:        "1.10",
+					RetryPolicy: core.RetryPolicy{Retries: &three},
+					Assertions: core.Assertions{
+						StatusCode: core.StatusCodes{"201"},
+						JSON:       map[string]core.JSONAssertion{"$.version": {Equals: json.RawMessage("2")}},
+					},
+				}},
+			},
+			"",
+		},
+		{"format flag overrides the file extension", "./testdata/suite1.json", loader.FormatYAML, &core.Testsuite{Tests: []core.TestCase{{Name: "test case 1", URL: "https://github.com/amad/smoker"}}}, ""},
+		{"should report line of syntax errors", "./testdata/invalid.yaml", "", &core.Testsuite{}, "unable to parse config file: line 4: mapping values are not allowed in this context"},
+		{"should report position of recursive aliases", "./testdata/recursive.yaml", "", &core.Testsuite{}, "unable to parse config file: line 4, column 12: alias *tests refers to a value containing it"},
+		{"should report position of recursive merge keys", "./testdata/recursivemerge.yaml", "", &core.Testsuite{}, "unable to parse config file: line 5, column 9: alias *test refers to a value containing it"},
+		{"should limit alias expansion", "./testdata/aliases.yaml", "", &core.Testsuite{}, "too many aliases, a document can expand at most 10000"},
+		{"should report position of type errors", "./testdata/badtype.yaml", "", &core.Testsuite{}, "unable to parse config file: line 4, column 14: cannot use string as int"},
+		{"should report position of nested type errors", "./testdata/nestedtype.yaml", "", &core.Testsuite{}, "unable to parse config file: line 8, column 17: cannot use number as string in field \"type\""},
+		{"should report position of invalid values", "./testdata/badvalue.yaml", "", &core.Testsuite{}, "unable to parse config file: line 4, column 14: invalid duration \"abc\""},
+		{"should error on unsupported format", "./testdata/suite1.yaml", "toml", &core.Testsuite{}, "unsupported format \"toml\""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := loader.LoadTestsuite(tc.filename, tc.format)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if !reflect.DeepEqual(tc.expectRes, res) {
+				t.Fatalf("Response does not match\nexpected: %+v\nreceived: %+v", *tc.expectRes, *res)
+			}
+		})
+	}
+}
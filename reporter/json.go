@@ -0,0 +1,155 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+type jsonEntry struct {
+	Index      int     `json:"index"`
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	SkipReason string  `json:"skipReason,omitempty"`
+	Duration   float64 `json:"duration"`
+	URL        string  `json:"url"`
+	Method     string  `json:"method"`
+	StatusCode int     `json:"statusCode,omitempty"`
+	RemoteAddr string  `json:"remoteAddr,omitempty"`
+	Source     string  `json:"source,omitempty"`
+	// StartedAt and FinishedAt are omitted for skipped test cases.
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Attempts   int        `json:"attempts"`
+	Retried    bool       `json:"retried"`
+}
+
+type jsonDocument struct {
+	StartedAt time.Time   `json:"startedAt"`
+	Duration  float64     `json:"duration"`
+	Total     int         `json:"total"`
+	Passed    int         `json:"passed"`
+	Failed    int         `json:"failed"`
+	Cancelled int         `json:"cancelled"`
+	Skipped   int         `json:"skipped"`
+	Results   []jsonEntry `json:"results"`
+}
+
+// JSON writes one JSON document with the results of all test cases when the run finishes.
+type JSON struct {
+	Nop
+	w io.Writer
+}
+
+// NewJSON creates a JSON reporter writing to w.
+func NewJSON(w io.Writer) *JSON {
+	return &JSON{w: w}
+}
+
+// SuiteFinished writes the report.
+func (j *JSON) SuiteFinished(s core.SuiteSummary) error {
+	if err := WriteJSON(j.w, s.Reports, s.StartedAt, s.Elapsed); err != nil {
+		return fmt.Errorf("unable to write %s report: %w", FormatJSON, err)
+	}
+
+	return nil
+}
+
+// JSONLines writes one line of JSON as soon as each test case finishes.
+type JSONLines struct {
+	Nop
+	w io.Writer
+}
+
+// NewJSONLines creates a JSONLines reporter writing to w.
+func NewJSONLines(w io.Writer) *JSONLines {
+	return &JSONLines{w: w}
+}
+
+// TestFinished writes the result of a test case.
+func (j *JSONLines) TestFinished(r *core.TestReport) error {
+	if err := WriteJSONLine(j.w, r); err != nil {
+		return fmt.Errorf("unable to write %s report: %w", FormatJSONLines, err)
+	}
+
+	return nil
+}
+
+func newJSONEntry(r *core.TestReport) jsonEntry {
+	method := strings.ToUpper(r.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	return jsonEntry{
+		Index:      r.Index,
+		Name:       r.Name,
+		Status:     r.StatusText(),
+		Error:      errorText(r),
+		SkipReason: r.SkipReason,
+		Duration:   r.Duration.Seconds(),
+		URL:        r.URL,
+		Method:     method,
+		StatusCode: r.StatusCode,
+		RemoteAddr: r.RemoteAddr,
+		Source:     r.Source,
+		StartedAt:  timeOrNil(r.StartedAt),
+		FinishedAt: timeOrNil(r.FinishedAt),
+		Attempts:   r.Attempts,
+		Retried:    r.Retried(),
+	}
+}
+
+// timeOrNil returns nil for the zero time, so it is omitted.
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// WriteJSONLine writes a test report as one line of JSON.
+func WriteJSONLine(w io.Writer, r *core.TestReport) error {
+	return json.NewEncoder(w).Encode(newJSONEntry(r))
+}
+
+// WriteJSON writes test reports as one JSON document, ordered by the index of the test cases.
+func WriteJSON(w io.Writer, reports []*core.TestReport, start time.Time, elapsed time.Duration) error {
+	sorted := append([]*core.TestReport{}, reports...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	doc := jsonDocument{
+		StartedAt: start,
+		Duration:  elapsed.Seconds(),
+		Total:     len(sorted),
+		Results:   []jsonEntry{},
+	}
+
+	for _, r := range sorted {
+		switch {
+		case r.Passed():
+			doc.Passed++
+		case r.Cancelled():
+			doc.Cancelled++
+		case r.Skipped():
+			doc.Skipped++
+		default:
+			doc.Failed++
+		}
+
+		doc.Results = append(doc.Results, newJSONEntry(r))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
@@ -0,0 +1,137 @@
+package reporter_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/reporter"
+)
+
+var start = time.Date(2020, 5, 1, 10, 30, 0, 0, time.UTC)
+
+var jsonReports = []*core.TestReport{
+	{
+		Index:      2,
+		Name:       "fails",
+		Status:     core.StatusFailed,
+		Err:        errors.New("expected status-code: 200 received: 500"),
+		Duration:   1500 * time.Millisecond,
+		Source:     "suites/api.json",
+		URL:        "https://example.com/api",
+		Method:     "post",
+		StatusCode: 500,
+		StartedAt:  start.Add(250 * time.Millisecond),
+		FinishedAt: start.Add(1750 * time.Millisecond),
+		Attempts:   3,
+	},
+	{
+		Index:      1,
+		Name:       "passes",
+		Status:     core.StatusPassed,
+		Duration:   250 * time.Millisecond,
+		URL:        "https://example.com",
+		StatusCode: 200,
+		RemoteAddr: "93.184.216.34:443",
+		StartedAt:  start,
+		FinishedAt: start.Add(250 * time.Millisecond),
+		Attempts:   1,
+	},
+	{
+		Index:      3,
+		Name:       "skipped",
+		Status:     core.StatusSkipped,
+		URL:        "https://example.com/beta",
+		SkipReason: "feature is not released",
+	},
+}
+
+func TestWriteJSONLine(t *testing.T) {
+	t.Parallel()
+
+	expected := `{"index":2,"name":"fails","status":"failed","error":"expected status-code: 200 received: 500","duration":1.5,"url":"https://example.com/api","method":"POST","statusCode":500,"source":"suites/api.json","startedAt":"2020-05-01T10:30:00.25Z","finishedAt":"2020-05-01T10:30:01.75Z","attempts":3,"retried":true}
+{"index":1,"name":"passes","status":"passed","duration":0.25,"url":"https://example.com","method":"GET","statusCode":200,"remoteAddr":"93.184.216.34:443","startedAt":"2020-05-01T10:30:00Z","finishedAt":"2020-05-01T10:30:00.25Z","attempts":1,"retried":false}
+{"index":3,"name":"skipped","status":"skipped","skipReason":"feature is not released","duration":0,"url":"https://example.com/beta","method":"GET","attempts":0,"retried":false}
+`
+
+	var buffer bytes.Buffer
+
+	for _, r := range jsonReports {
+		if err := reporter.WriteJSONLine(&buffer, r); err != nil {
+			t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+		}
+	}
+
+	if buffer.String() != expected {
+		t.Fatalf("JSON lines do not match\nexpected: %s\nreceived: %s", expected, buffer.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	expected := `{
+  "startedAt": "2020-05-01T10:30:00Z",
+  "duration": 2,
+  "total": 3,
+  "passed": 1,
+  "failed": 1,
+  "cancelled": 0,
+  "skipped": 1,
+  "results": [
+    {
+      "index": 1,
+      "name": "passes",
+      "status": "passed",
+      "duration": 0.25,
+      "url": "https://example.com",
+      "method": "GET",
+      "statusCode": 200,
+      "remoteAddr": "93.184.216.34:443",
+      "startedAt": "2020-05-01T10:30:00Z",
+      "finishedAt": "2020-05-01T10:30:00.25Z",
+      "attempts": 1,
+      "retried": false
+    },
+    {
+      "index": 2,
+      "name": "fails",
+      "status": "failed",
+      "error": "expected status-code: 200 received: 500",
+      "duration": 1.5,
+      "url": "https://example.com/api",
+      "method": "POST",
+      "statusCode": 500,
+      "source": "suites/api.json",
+      "startedAt": "2020-05-01T10:30:00.25Z",
+      "finishedAt": "2020-05-01T10:30:01.75Z",
+      "attempts": 3,
+      "retried": true
+    },
+    {
+      "index": 3,
+      "name": "skipped",
+      "status": "skipped",
+      "skipReason": "feature is not released",
+      "duration": 0,
+      "url": "https://example.com/beta",
+      "method": "GET",
+      "attempts": 0,
+      "retried": false
+    }
+  ]
+}
+`
+
+	var buffer bytes.Buffer
+
+	if err := reporter.WriteJSON(&buffer, jsonReports, start, 2*time.Second); err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	if buffer.String() != expected {
+		t.Fatalf("JSON report does not match\nexpected: %s\nreceived: %s", expected, buffer.String())
+	}
+}
@@ -0,0 +1,137 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+type junitTestsuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Testcases []junitTestcase `xml:"testcase"`
+}
+
+type junitTestcase struct {
+	Name      string `xml:"name,attr"`
+	Classname string `xml:"classname,attr"`
+	File      string `xml:"file,attr,omitempty"`
+	Time      string `xml:"time,attr"`
+	// Properties hold the address the request was sent to.
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitFailure    `xml:"failure,omitempty"`
+	Skipped    *junitSkipped    `xml:"skipped,omitempty"`
+}
+
+type junitProperties struct {
+	Property []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit writes a JUnit XML testsuite when the run finishes.
+type JUnit struct {
+	Nop
+	w io.Writer
+}
+
+// NewJUnit creates a JUnit reporter writing to w.
+func NewJUnit(w io.Writer) *JUnit {
+	return &JUnit{w: w}
+}
+
+// SuiteFinished writes the report.
+func (j *JUnit) SuiteFinished(s core.SuiteSummary) error {
+	if err := WriteJUnit(j.w, s.Reports, s.StartedAt, s.Elapsed); err != nil {
+		return fmt.Errorf("unable to write %s report: %w", FormatJUnit, err)
+	}
+
+	return nil
+}
+
+// WriteJUnit writes test reports as one JUnit XML testsuite, ordered by
+// the index of the test cases.
+func WriteJUnit(w io.Writer, reports []*core.TestReport, start time.Time, elapsed time.Duration) error {
+	sorted := append([]*core.TestReport{}, reports...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	suite := junitTestsuite{
+		Name:      "smoker",
+		Tests:     len(sorted),
+		Time:      seconds(elapsed),
+		Timestamp: start.Format("2006-01-02T15:04:05"),
+	}
+
+	for _, r := range sorted {
+		tc := junitTestcase{
+			Name:      r.Name,
+			Classname: "smoker",
+			File:      r.Source,
+			Time:      seconds(r.Duration),
+		}
+
+		if r.Source != "" {
+			tc.Classname = r.Source
+		}
+
+		if r.RemoteAddr != "" {
+			tc.Properties = &junitProperties{Property: []junitProperty{{Name: "remoteAddr", Value: r.RemoteAddr}}}
+		}
+
+		switch {
+		case r.Passed():
+		case r.Skipped():
+			suite.Skipped++
+			tc.Skipped = &junitSkipped{Message: r.SkipReason}
+		case r.Cancelled():
+			suite.Skipped++
+			tc.Skipped = &junitSkipped{Message: r.StatusText()}
+		default:
+			suite.Failures++
+			tc.Failure = &junitFailure{Message: errorText(r), Text: r.String()}
+		}
+
+		suite.Testcases = append(suite.Testcases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,53 @@
+package reporter_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/reporter"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	t.Parallel()
+
+	reports := []*core.TestReport{
+		{Index: 2, Name: "fails <html>", Status: core.StatusFailed, Err: errors.New("expected status-code: 200 received: 500"), Duration: 1500 * time.Millisecond, Source: "suites/api.json"},
+		{Index: 1, Name: "passes", Status: core.StatusPassed, Duration: 250 * time.Millisecond, RemoteAddr: "10.0.0.5:443"},
+		{Index: 3, Name: "cancelled", Status: core.StatusCancelled, Err: context.Canceled, Duration: 100 * time.Millisecond},
+		{Index: 4, Name: "skipped", Status: core.StatusSkipped, SkipReason: "endpoint is broken"},
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="smoker" tests="4" failures="1" errors="0" skipped="2" time="2.000" timestamp="2020-05-01T10:30:00">
+  <testcase name="passes" classname="smoker" time="0.250">
+    <properties>
+      <property name="remoteAddr" value="10.0.0.5:443"></property>
+    </properties>
+  </testcase>
+  <testcase name="fails &lt;html&gt;" classname="suites/api.json" file="suites/api.json" time="1.500">
+    <failure message="expected status-code: 200 received: 500">FAIL: testcase #2 &#34;fails &lt;html&gt;&#34; in suites/api.json expected status-code: 200 received: 500 (1.50s)</failure>
+  </testcase>
+  <testcase name="cancelled" classname="smoker" time="0.100">
+    <skipped message="cancelled"></skipped>
+  </testcase>
+  <testcase name="skipped" classname="smoker" time="0.000">
+    <skipped message="endpoint is broken"></skipped>
+  </testcase>
+</testsuite>
+`
+
+	var buffer bytes.Buffer
+
+	err := reporter.WriteJUnit(&buffer, reports, time.Date(2020, 5, 1, 10, 30, 0, 0, time.UTC), 2*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	if buffer.String() != expected {
+		t.Fatalf("JUnit report does not match\nexpected: %s\nreceived: %s", expected, buffer.String())
+	}
+}
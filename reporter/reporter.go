@@ -0,0 +1,95 @@
+// Package reporter implements the core.Reporter interface for the output
+// formats supported by smoker.
+package reporter
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/amad/smoker/core"
+)
+
+// Report formats supported by New.
+const (
+	// FormatJUnit writes a JUnit XML testsuite when the run finishes.
+	FormatJUnit = "junit"
+	// FormatJSON writes one JSON document when the run finishes.
+	FormatJSON = "json"
+	// FormatJSONLines writes one line of JSON as soon as each test case finishes.
+	FormatJSONLines = "jsonl"
+)
+
+// New returns a reporter writing the given format to w.
+func New(format string, w io.Writer) (core.Reporter, error) {
+	switch format {
+	case FormatJUnit:
+		return NewJUnit(w), nil
+	case FormatJSON:
+		return NewJSON(w), nil
+	case FormatJSONLines:
+		return NewJSONLines(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+// Nop is a reporter that ignores all events. It can be embedded by
+// reporters interested in some of the events only.
+type Nop struct{}
+
+// SuiteStarted implements core.Reporter.
+func (Nop) SuiteStarted(core.SuiteStart) error { return nil }
+
+// TestStarted implements core.Reporter.
+func (Nop) TestStarted(core.TestStart) error { return nil }
+
+// TestFinished implements core.Reporter.
+func (Nop) TestFinished(*core.TestReport) error { return nil }
+
+// SuiteFinished implements core.Reporter.
+func (Nop) SuiteFinished(core.SuiteSummary) error { return nil }
+
+// Multi returns a reporter that sends every event to all the given reporters,
+// in order. All reporters receive the event even if one fails, the first
+// error is returned.
+func Multi(reporters ...core.Reporter) core.Reporter {
+	return multiReporter(append([]core.Reporter{}, reporters...))
+}
+
+type multiReporter []core.Reporter
+
+func (m multiReporter) each(event func(rp core.Reporter) error) error {
+	var first error
+
+	for _, rp := range m {
+		if err := event(rp); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+func (m multiReporter) SuiteStarted(s core.SuiteStart) error {
+	return m.each(func(rp core.Reporter) error { return rp.SuiteStarted(s) })
+}
+
+func (m multiReporter) TestStarted(t core.TestStart) error {
+	return m.each(func(rp core.Reporter) error { return rp.TestStarted(t) })
+}
+
+func (m multiReporter) TestFinished(r *core.TestReport) error {
+	return m.each(func(rp core.Reporter) error { return rp.TestFinished(r) })
+}
+
+func (m multiReporter) SuiteFinished(s core.SuiteSummary) error {
+	return m.each(func(rp core.Reporter) error { return rp.SuiteFinished(s) })
+}
+
+func errorText(r *core.TestReport) string {
+	if r.Err == nil {
+		return ""
+	}
+
+	return r.Err.Error()
+}
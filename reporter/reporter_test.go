@@ -0,0 +1,83 @@
+package reporter_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/reporter"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		format        string
+		expectedError string
+	}{
+		{reporter.FormatJUnit, ""},
+		{reporter.FormatJSON, ""},
+		{reporter.FormatJSONLines, ""},
+		{"html", "unsupported report format \"html\""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.format, func(t *testing.T) {
+			rp, err := reporter.New(tc.format, &bytes.Buffer{})
+
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %v", tc.expectedError, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			if rp == nil {
+				t.Fatal("Expected a reporter")
+			}
+		})
+	}
+}
+
+type failingReporter struct {
+	reporter.Nop
+	err      error
+	finished int
+}
+
+func (r *failingReporter) TestFinished(*core.TestReport) error {
+	r.finished++
+	return r.err
+}
+
+func TestMulti(t *testing.T) {
+	t.Parallel()
+
+	first := &failingReporter{err: errors.New("first")}
+	second := &failingReporter{err: errors.New("second")}
+	third := &failingReporter{}
+
+	rp := reporter.Multi(first, second, third)
+
+	if err := rp.SuiteStarted(core.SuiteStart{}); err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	err := rp.TestFinished(&core.TestReport{})
+	if err == nil || err.Error() != "first" {
+		t.Fatalf("Expected error does not match\nexpected: first\nreceived: %v", err)
+	}
+
+	for i, r := range []*failingReporter{first, second, third} {
+		if r.finished != 1 {
+			t.Fatalf("Expected reporter %d to receive 1 event, got %d", i+1, r.finished)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+// Text writes the human readable output of a run. Failed and cancelled test
+// cases are written to stderr, everything else to stdout.
+type Text struct {
+	stdout, stderr io.StringWriter
+}
+
+// NewText creates a Text reporter.
+func NewText(stdout io.StringWriter, stderr io.StringWriter) *Text {
+	return &Text{stdout: stdout, stderr: stderr}
+}
+
+// SuiteStarted writes the settings of the run.
+func (t *Text) SuiteStarted(s core.SuiteStart) error {
+	tests := fmt.Sprintf("Tests:   %d total", s.Tests)
+	if s.Filtered > 0 {
+		tests += fmt.Sprintf(" (%d filtered out)", s.Filtered)
+	}
+
+	lines := []string{
+		tests,
+		fmt.Sprintf("Workers: %d total", s.Workers),
+		fmt.Sprintf("Timeout: %s", s.Timeout.String()),
+	}
+
+	suites, overrides := timeoutOverrides(s)
+
+	if len(suites) > 0 {
+		lines = append(lines, fmt.Sprintf("Testsuite timeouts: %s", strings.Join(suites, ", ")))
+	}
+
+	if len(overrides) > 0 {
+		lines = append(lines, fmt.Sprintf("Timeout overrides: %s", strings.Join(overrides, ", ")))
+	}
+
+	lines = append(lines, fmt.Sprintf("Stop on failure: %t\n", s.StopOnFailure), "Waiting for results\n")
+
+	for _, line := range lines {
+		if err := t.printfOut("%s", line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TestStarted implements core.Reporter, the text output only shows finished test cases.
+func (t *Text) TestStarted(core.TestStart) error {
+	return nil
+}
+
+// TestFinished writes the result of a test case.
+func (t *Text) TestFinished(r *core.TestReport) error {
+	if r.Passed() || r.Skipped() {
+		return t.printfOut("%s", r.String())
+	}
+
+	return t.printfErrOut("%s", r.String())
+}
+
+// SuiteFinished writes the elapsed time of the run.
+func (t *Text) SuiteFinished(s core.SuiteSummary) error {
+	return t.printfOut("\nElapsed: %.2fs", s.Elapsed.Seconds())
+}
+
+// timeoutOverrides lists the testsuite files with a default timeout other
+// than the one of the run, and the test cases with a timeout other than the
+// default of their testsuite file.
+func timeoutOverrides(s core.SuiteStart) ([]string, []string) {
+	var suites, overrides []string
+
+	listed := make(map[string]bool)
+
+	for _, tc := range s.TestCases {
+		defaultTimeout := s.Timeout
+
+		if suiteTimeout := time.Duration(tc.DefaultTimeout); suiteTimeout != 0 {
+			defaultTimeout = suiteTimeout
+
+			if suiteTimeout != s.Timeout && !listed[tc.Source] {
+				listed[tc.Source] = true
+				suites = append(suites, fmt.Sprintf("%s %s", tc.Source, suiteTimeout))
+			}
+		}
+
+		if timeout := time.Duration(tc.Timeout); timeout != 0 && timeout != defaultTimeout {
+			overrides = append(overrides, fmt.Sprintf("\"%s\" %s", tc.Name, timeout))
+		}
+	}
+
+	return suites, overrides
+}
+
+func (t *Text) printfOut(msg string, params ...interface{}) error {
+	_, err := t.stdout.WriteString(fmt.Sprintf(msg, params...) + "\n")
+	return err
+}
+
+func (t *Text) printfErrOut(msg string, params ...interface{}) error {
+	_, err := t.stderr.WriteString(fmt.Sprintf(msg, params...) + "\n")
+	return err
+}
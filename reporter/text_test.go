@@ -0,0 +1,77 @@
+package reporter_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/reporter"
+)
+
+func TestText(t *testing.T) {
+	t.Parallel()
+
+	expectedOutput := `Tests:   2 total (3 filtered out)
+Workers: 1 total
+Timeout: 10s
+Testsuite timeouts: api.yaml 5s
+Timeout overrides: "b" 30s, "d" 10s
+Stop on failure: false
+
+Waiting for results
+
+PASS: testcase #1 "a 100%" (0.25s)
+SKIP: testcase #3 "c" endpoint is broken
+
+Elapsed: 2.00s
+`
+	expectedErrOutput := "FAIL: testcase #2 \"b\" reason (1.50s)\n"
+
+	var stdout, stderr bytes.Buffer
+
+	rp := reporter.NewText(&stdout, &stderr)
+
+	events := []func() error{
+		func() error {
+			return rp.SuiteStarted(core.SuiteStart{
+				Tests:    2,
+				Filtered: 3,
+				Workers:  1,
+				Timeout:  10 * time.Second,
+				TestCases: []core.TestCase{
+					{Name: "a 100%", Timeout: core.Duration(10 * time.Second)},
+					{Name: "b", Timeout: core.Duration(30 * time.Second)},
+					{Name: "c", Timeout: core.Duration(5 * time.Second), DefaultTimeout: core.Duration(5 * time.Second), Source: "api.yaml"},
+					{Name: "d", Timeout: core.Duration(10 * time.Second), DefaultTimeout: core.Duration(5 * time.Second), Source: "api.yaml"},
+				},
+			})
+		},
+		func() error { return rp.TestStarted(core.TestStart{Index: 1, TestCase: core.TestCase{Name: "a 100%"}}) },
+		func() error {
+			return rp.TestFinished(&core.TestReport{Index: 1, Name: "a 100%", Status: core.StatusPassed, Duration: 250 * time.Millisecond})
+		},
+		func() error {
+			return rp.TestFinished(&core.TestReport{Index: 2, Name: "b", Err: errors.New("reason"), Duration: 1500 * time.Millisecond})
+		},
+		func() error {
+			return rp.TestFinished(&core.TestReport{Index: 3, Name: "c", Status: core.StatusSkipped, SkipReason: "endpoint is broken"})
+		},
+		func() error { return rp.SuiteFinished(core.SuiteSummary{Elapsed: 2 * time.Second}) },
+	}
+
+	for _, event := range events {
+		if err := event(); err != nil {
+			t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+		}
+	}
+
+	if stdout.String() != expectedOutput {
+		t.Fatalf("stdout output does not match\nexpected: %s\nreceived: %s", expectedOutput, stdout.String())
+	}
+
+	if stderr.String() != expectedErrOutput {
+		t.Fatalf("stderr output does not match\nexpected: %s\nreceived: %s", expectedErrOutput, stderr.String())
+	}
+}
@@ -0,0 +1,227 @@
+package requester
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+// tokenRefreshMargin is how long before its expiry an OAuth2 token is
+// fetched again. Tokens living less than twice the margin are fetched again
+// after half of their lifetime.
+const tokenRefreshMargin = 30 * time.Second
+
+// maxErrorBody is the size of the token response body shown in errors.
+const maxErrorBody = 200
+
+// authorize sets the Authorization header of the request. The header of the
+// test case is kept when it sets one. client sends the OAuth2 token requests.
+func (r *Requester) authorize(ctx context.Context, client *http.Client, req *http.Request, auth *core.Auth) error {
+	if auth == nil || req.Header.Get("Authorization") != "" {
+		return nil
+	}
+
+	set := 0
+	for _, ok := range []bool{auth.Basic != nil, auth.Bearer != nil, auth.OAuth2 != nil} {
+		if ok {
+			set++
+		}
+	}
+
+	if set > 1 {
+		return errors.New("auth must set only one of basic, bearer or oauth2")
+	}
+
+	switch {
+	case auth.Basic != nil:
+		req.SetBasicAuth(auth.Basic.Username, auth.Basic.Password)
+	case auth.Bearer != nil:
+		token, err := bearerToken(auth.Bearer)
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+	case auth.OAuth2 != nil:
+		token, err := r.oauth2Token(ctx, client, auth.OAuth2)
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return nil
+}
+
+// bearerToken returns the token of the test case, reading it from the
+// environment or from a file.
+func bearerToken(bearer *core.BearerAuth) (string, error) {
+	var token string
+
+	switch {
+	case bearer.Token != "" && (bearer.Env != "" || bearer.File != ""), bearer.Env != "" && bearer.File != "":
+		return "", errors.New("auth bearer must set only one of token, env or file")
+	case bearer.Env != "":
+		v, ok := os.LookupEnv(bearer.Env)
+		if !ok {
+			return "", fmt.Errorf("auth bearer env %s is not set", bearer.Env)
+		}
+
+		token = v
+	case bearer.File != "":
+		b, err := ioutil.ReadFile(bearer.File)
+		if err != nil {
+			return "", fmt.Errorf("unable to read auth bearer file: %w", err)
+		}
+
+		token = string(b)
+	default:
+		token = bearer.Token
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", errors.New("auth bearer token is empty")
+	}
+
+	return token, nil
+}
+
+// oauth2Key identifies the tokens shared by the test cases.
+type oauth2Key struct {
+	tokenURL, clientID, clientSecret, scopes string
+}
+
+// oauth2Token is a cached token. mu is held while the token is fetched, so
+// the workers needing the token wait for a single token request.
+type oauth2Token struct {
+	mu          sync.Mutex
+	accessToken string
+	// refreshAt is zero when the token does not expire.
+	refreshAt time.Time
+}
+
+// oauth2Token returns a valid token of the client credentials, fetching a new
+// one when there is none or it is about to expire.
+func (r *Requester) oauth2Token(ctx context.Context, client *http.Client, cfg *core.OAuth2Auth) (string, error) {
+	if cfg.TokenURL == "" || cfg.ClientID == "" {
+		return "", errors.New("auth oauth2 must set tokenURL and clientID")
+	}
+
+	key := oauth2Key{
+		tokenURL:     cfg.TokenURL,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		scopes:       strings.Join(cfg.Scopes, " "),
+	}
+
+	r.tokensMu.Lock()
+	if r.tokens == nil {
+		r.tokens = make(map[oauth2Key]*oauth2Token)
+	}
+
+	token, ok := r.tokens[key]
+	if !ok {
+		token = &oauth2Token{}
+		r.tokens[key] = token
+	}
+	r.tokensMu.Unlock()
+
+	token.mu.Lock()
+	defer token.mu.Unlock()
+
+	if token.accessToken != "" && (token.refreshAt.IsZero() || time.Now().Before(token.refreshAt)) {
+		return token.accessToken, nil
+	}
+
+	accessToken, expiresIn, err := fetchToken(ctx, client, key)
+	if err != nil {
+		return "", err
+	}
+
+	token.accessToken = accessToken
+	token.refreshAt = time.Time{}
+
+	if expiresIn > 0 {
+		lifetime := time.Duration(expiresIn) * time.Second
+		margin := tokenRefreshMargin
+		if lifetime < 2*margin {
+			margin = lifetime / 2
+		}
+
+		token.refreshAt = time.Now().Add(lifetime - margin)
+	}
+
+	return token.accessToken, nil
+}
+
+// tokenResponse is the response of a token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// fetchToken requests a token with the client credentials grant, sending the
+// client credentials with basic auth.
+func fetchToken(ctx context.Context, client *http.Client, key oauth2Key) (string, int64, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if key.scopes != "" {
+		form.Set("scope", key.scopes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, key.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("could not create oauth2 token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(key.clientID), url.QueryEscape(key.clientSecret))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2 token request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	raw, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("unable to read the oauth2 token response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		if len(raw) > maxErrorBody {
+			raw = append(raw[:maxErrorBody:maxErrorBody], "..."...)
+		}
+
+		return "", 0, fmt.Errorf("oauth2 token request failed with status-code %d: %s", res.StatusCode, strconv.Quote(string(raw)))
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return "", 0, fmt.Errorf("oauth2 token response is not valid JSON: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return "", 0, errors.New("oauth2 token response does not have access_token")
+	}
+
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", 0, fmt.Errorf("oauth2 token type must be bearer received %s", token.TokenType)
+	}
+
+	return token.AccessToken, token.ExpiresIn, nil
+}
@@ -0,0 +1,276 @@
+package requester
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "smoker-auth")
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	emptyFile := filepath.Join(dir, "empty")
+	if err := ioutil.WriteFile(emptyFile, nil, 0600); err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	os.Setenv("SMOKER_TEST_AUTH_TOKEN", "env-token")
+	defer os.Unsetenv("SMOKER_TEST_AUTH_TOKEN")
+
+	requester := NewRequester(expectedTimeout, expectedUserAgent)
+
+	tt := []struct {
+		name      string
+		auth      *core.Auth
+		headers   map[string]string
+		expected  string
+		expectErr string
+	}{
+		{
+			name:     "basic",
+			auth:     &core.Auth{Basic: &core.BasicAuth{Username: "admin", Password: "secret"}},
+			expected: "Basic YWRtaW46c2VjcmV0",
+		},
+		{
+			name:     "bearer token",
+			auth:     &core.Auth{Bearer: &core.BearerAuth{Token: "static-token"}},
+			expected: "Bearer static-token",
+		},
+		{
+			name:     "bearer token from env",
+			auth:     &core.Auth{Bearer: &core.BearerAuth{Env: "SMOKER_TEST_AUTH_TOKEN"}},
+			expected: "Bearer env-token",
+		},
+		{
+			name:     "bearer token from file",
+			auth:     &core.Auth{Bearer: &core.BearerAuth{File: tokenFile}},
+			expected: "Bearer file-token",
+		},
+		{
+			name:     "authorization header takes precedence",
+			auth:     &core.Auth{Bearer: &core.BearerAuth{Token: "static-token"}},
+			headers:  map[string]string{"authorization": "Token custom"},
+			expected: "Token custom",
+		},
+		{
+			name:     "empty auth does not authenticate",
+			auth:     &core.Auth{},
+			expected: "",
+		},
+		{
+			name:      "errors when more than one kind is set",
+			auth:      &core.Auth{Basic: &core.BasicAuth{}, Bearer: &core.BearerAuth{Token: "static-token"}},
+			expectErr: "auth must set only one of basic, bearer or oauth2",
+		},
+		{
+			name:      "errors when more than one token source is set",
+			auth:      &core.Auth{Bearer: &core.BearerAuth{Token: "static-token", File: tokenFile}},
+			expectErr: "auth bearer must set only one of token, env or file",
+		},
+		{
+			name:      "errors when env is not set",
+			auth:      &core.Auth{Bearer: &core.BearerAuth{Env: "SMOKER_TEST_AUTH_MISSING"}},
+			expectErr: "auth bearer env SMOKER_TEST_AUTH_MISSING is not set",
+		},
+		{
+			name:      "errors when file can not be read",
+			auth:      &core.Auth{Bearer: &core.BearerAuth{File: filepath.Join(dir, "missing")}},
+			expectErr: "unable to read auth bearer file",
+		},
+		{
+			name:      "errors when token is empty",
+			auth:      &core.Auth{Bearer: &core.BearerAuth{File: emptyFile}},
+			expectErr: "auth bearer token is empty",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			test := core.TestCase{
+				Name:       tc.name,
+				URL:        server.URL,
+				Headers:    tc.headers,
+				Auth:       tc.auth,
+				Assertions: core.Assertions{Body: []string{"^" + tc.expected + "$"}},
+			}
+
+			_, err := requester.Request(context.Background(), test)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestOAuth2(t *testing.T) {
+	t.Parallel()
+
+	var fetches int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if r.Method != http.MethodPost || r.FormValue("grant_type") != "client_credentials" || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+
+		n := atomic.AddInt32(&fetches, 1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600,"scope":%q}`, n, r.FormValue("scope"))
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	requester := NewRequester(expectedTimeout, expectedUserAgent)
+	auth := &core.Auth{OAuth2: &core.OAuth2Auth{TokenURL: server.URL + "/token", ClientID: "client", ClientSecret: "secret", Scopes: []string{"read", "write"}}}
+
+	request := func(name string, expected string) error {
+		_, err := requester.Request(context.Background(), core.TestCase{
+			Name:       name,
+			URL:        server.URL + "/api",
+			Auth:       auth,
+			Assertions: core.Assertions{Body: []string{"^Bearer " + expected + "$"}},
+		})
+
+		return err
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- request(fmt.Sprintf("worker %d", i), "token-1")
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+		}
+	}
+
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Fatalf("Expected workers to share the token\nexpected: 1 token request\nreceived: %d token requests", n)
+	}
+
+	for _, token := range requester.tokens {
+		token.refreshAt = time.Now().Add(-time.Second)
+	}
+
+	if err := request("refreshes the token", "token-2"); err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	tt := []struct {
+		name      string
+		auth      *core.OAuth2Auth
+		expectErr string
+	}{
+		{
+			name:      "errors when token url is not set",
+			auth:      &core.OAuth2Auth{ClientID: "client"},
+			expectErr: "auth oauth2 must set tokenURL and clientID",
+		},
+		{
+			name:      "errors when credentials are rejected",
+			auth:      &core.OAuth2Auth{TokenURL: server.URL + "/token", ClientID: "client", ClientSecret: "wrong"},
+			expectErr: `oauth2 token request failed with status-code 401: "{\"error\":\"invalid_client\"}"`,
+		},
+		{
+			name:      "errors when response has no token",
+			auth:      &core.OAuth2Auth{TokenURL: server.URL + "/api", ClientID: "client"},
+			expectErr: "oauth2 token response is not valid JSON",
+		},
+	}
+
+	for _, tc := range tt {
+		_, err := requester.Request(context.Background(), core.TestCase{Name: tc.name, URL: server.URL + "/api", Auth: &core.Auth{OAuth2: tc.auth}})
+
+		if err == nil {
+			t.Fatalf("%s: Expected to throw error\nexpected: %s\nreceived: <nil>", tc.name, tc.expectErr)
+		}
+
+		if !strings.Contains(err.Error(), tc.expectErr) {
+			t.Fatalf("%s: Expected error does not match\nexpected: %s\nreceived: %s", tc.name, tc.expectErr, err.Error())
+		}
+	}
+}
+
+func TestTokenRefreshMargin(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"short","expires_in":20}`))
+	}))
+	defer server.Close()
+
+	requester := NewRequester(expectedTimeout, expectedUserAgent)
+
+	before := time.Now()
+
+	token, err := requester.oauth2Token(context.Background(), requester.client, &core.OAuth2Auth{TokenURL: server.URL, ClientID: "client"})
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	if token != "short" {
+		t.Fatalf("Token does not match\nexpected: short\nreceived: %s", token)
+	}
+
+	for _, cached := range requester.tokens {
+		if refreshIn := cached.refreshAt.Sub(before); refreshIn < 10*time.Second || refreshIn > 11*time.Second {
+			t.Fatalf("Expected token to be refreshed after half of its lifetime\nexpected: 10s\nreceived: %s", refreshIn)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package requester
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/amad/smoker/core"
+)
+
+// sameSiteModes maps SameSite attributes to their name in assertions. The
+// default mode is a SameSite attribute without a value.
+var sameSiteModes = map[http.SameSite]string{
+	http.SameSiteDefaultMode: "",
+	http.SameSiteLaxMode:     "lax",
+	http.SameSiteStrictMode:  "strict",
+	http.SameSiteNoneMode:    "none",
+}
+
+// findCookie returns the last cookie with the given name, a cookie set again
+// replaces the previous one. It returns nil when no cookie has the name.
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	var found *http.Cookie
+
+	for _, c := range cookies {
+		if c.Name == name {
+			found = c
+		}
+	}
+
+	return found
+}
+
+// assertCookies checks the cookies set by the responses, redirects included,
+// against the cookie assertions, in the order of the cookie names. When a
+// cookie is set more than once, the last one is checked.
+func assertCookies(assertions map[string]core.CookieAssertion, cookies []*http.Cookie) error {
+	names := make([]string, 0, len(assertions))
+	for name := range assertions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := assertCookie(name, assertions[name], findCookie(cookies, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func assertCookie(name string, a core.CookieAssertion, cookie *http.Cookie) error {
+	if a.Exists != nil && !*a.Exists {
+		if cookie != nil {
+			return fmt.Errorf("expected response to not set cookie %s received %s", name, cookie)
+		}
+
+		return nil
+	}
+
+	if cookie == nil {
+		return fmt.Errorf("unable to find cookie %s in response", name)
+	}
+
+	if a.Value != nil && cookie.Value != *a.Value {
+		return fmt.Errorf("expected cookie %s value %q received %q", name, *a.Value, cookie.Value)
+	}
+
+	if a.Matches != "" {
+		matched, err := regexp.MatchString(a.Matches, cookie.Value)
+		if err != nil {
+			return fmt.Errorf("invalid regex /%s/ for cookie %s: %w", a.Matches, name, err)
+		}
+
+		if !matched {
+			return fmt.Errorf("expected cookie %s value to match /%s/ received %q", name, a.Matches, cookie.Value)
+		}
+	}
+
+	if err := assertCookieFlag(name, "Secure", a.Secure, cookie.Secure); err != nil {
+		return err
+	}
+
+	if err := assertCookieFlag(name, "HttpOnly", a.HTTPOnly, cookie.HttpOnly); err != nil {
+		return err
+	}
+
+	if a.SameSite != "" && !strings.EqualFold(a.SameSite, sameSiteModes[cookie.SameSite]) {
+		return fmt.Errorf("expected cookie %s SameSite=%s received %s", name, a.SameSite, describeSameSite(cookie.SameSite))
+	}
+
+	if a.Path != "" && cookie.Path != a.Path {
+		return fmt.Errorf("expected cookie %s Path=%s received Path=%s", name, a.Path, cookie.Path)
+	}
+
+	if a.Domain != "" && !strings.EqualFold(strings.TrimPrefix(cookie.Domain, "."), strings.TrimPrefix(a.Domain, ".")) {
+		return fmt.Errorf("expected cookie %s Domain=%s received Domain=%s", name, a.Domain, cookie.Domain)
+	}
+
+	return nil
+}
+
+func assertCookieFlag(name string, flag string, expected *bool, received bool) error {
+	if expected == nil || *expected == received {
+		return nil
+	}
+
+	if *expected {
+		return fmt.Errorf("expected cookie %s to be %s", name, flag)
+	}
+
+	return fmt.Errorf("expected cookie %s to not be %s", name, flag)
+}
+
+func describeSameSite(mode http.SameSite) string {
+	if name := sameSiteModes[mode]; name != "" {
+		return "SameSite=" + name
+	}
+
+	return "no SameSite attribute"
+}
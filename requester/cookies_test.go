@@ -0,0 +1,225 @@
+package requester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestAssertCookies(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{}
+	header.Add("Set-Cookie", "id=abc123; Path=/; Domain=example.com; Secure; HttpOnly; SameSite=Strict")
+	header.Add("Set-Cookie", "theme=light")
+	header.Add("Set-Cookie", "theme=dark; SameSite=Lax")
+	cookies := (&http.Response{Header: header}).Cookies()
+
+	yes, no := true, false
+	value := func(s string) *string { return &s }
+
+	tt := []struct {
+		name       string
+		assertions map[string]core.CookieAssertion
+		expectErr  string
+	}{
+		{
+			name: "all attributes",
+			assertions: map[string]core.CookieAssertion{
+				"id": {Value: value("abc123"), Matches: "^[a-z0-9]+$", Secure: &yes, HTTPOnly: &yes, SameSite: "strict", Path: "/", Domain: "example.com"},
+			},
+		},
+		{
+			name:       "last cookie of the same name",
+			assertions: map[string]core.CookieAssertion{"theme": {Value: value("dark"), Secure: &no, HTTPOnly: &no, SameSite: "Lax"}},
+		},
+		{
+			name:       "errors when cookie not found",
+			assertions: map[string]core.CookieAssertion{"session": {}},
+			expectErr:  "unable to find cookie session in response",
+		},
+		{
+			name:       "cookie must not be set",
+			assertions: map[string]core.CookieAssertion{"session": {Exists: &no}},
+		},
+		{
+			name:       "errors when cookie must not be set",
+			assertions: map[string]core.CookieAssertion{"theme": {Exists: &no}},
+			expectErr:  "expected response to not set cookie theme received theme=dark; SameSite=Lax",
+		},
+		{
+			name:       "errors when value does not match",
+			assertions: map[string]core.CookieAssertion{"id": {Value: value("xyz")}},
+			expectErr:  `expected cookie id value "xyz" received "abc123"`,
+		},
+		{
+			name:       "errors when value does not match regex",
+			assertions: map[string]core.CookieAssertion{"id": {Matches: "^[0-9]+$"}},
+			expectErr:  `expected cookie id value to match /^[0-9]+$/ received "abc123"`,
+		},
+		{
+			name:       "errors when cookie is not secure",
+			assertions: map[string]core.CookieAssertion{"theme": {Secure: &yes}},
+			expectErr:  "expected cookie theme to be Secure",
+		},
+		{
+			name:       "errors when cookie is http only",
+			assertions: map[string]core.CookieAssertion{"id": {HTTPOnly: &no}},
+			expectErr:  "expected cookie id to not be HttpOnly",
+		},
+		{
+			name:       "errors when same site does not match",
+			assertions: map[string]core.CookieAssertion{"theme": {SameSite: "strict"}},
+			expectErr:  "expected cookie theme SameSite=strict received SameSite=lax",
+		},
+		{
+			name:       "errors when path does not match",
+			assertions: map[string]core.CookieAssertion{"id": {Path: "/admin"}},
+			expectErr:  "expected cookie id Path=/admin received Path=/",
+		},
+		{
+			name:       "errors when domain does not match",
+			assertions: map[string]core.CookieAssertion{"id": {Domain: "example.org"}},
+			expectErr:  "expected cookie id Domain=example.org received Domain=example.com",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := assertCookies(tc.assertions, cookies)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "user", Value: r.URL.Query().Get("user"), Path: "/"})
+			return
+		}
+
+		cookie, err := r.Cookie("user")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(cookie.Value))
+	}))
+	defer server.Close()
+
+	requester := NewRequester(expectedTimeout, expectedUserAgent)
+
+	tt := []struct {
+		name      string
+		tc        core.TestCase
+		expectErr string
+	}{
+		{
+			name: "admin logs in",
+			tc:   core.TestCase{URL: server.URL + "/login?user=admin", Session: "admin", Assertions: core.Assertions{Cookies: map[string]core.CookieAssertion{"user": {}}}},
+		},
+		{
+			name: "guest logs in",
+			tc:   core.TestCase{URL: server.URL + "/login?user=guest", Session: "guest"},
+		},
+		{
+			name: "admin sends the session cookie",
+			tc:   core.TestCase{URL: server.URL + "/me", Session: "admin", Assertions: core.Assertions{Body: []string{"^admin$"}}},
+		},
+		{
+			name: "guest sends the session cookie",
+			tc:   core.TestCase{URL: server.URL + "/me", Session: "guest", Assertions: core.Assertions{Body: []string{"^guest$"}}},
+		},
+		{
+			name:      "request without session does not send cookies",
+			tc:        core.TestCase{URL: server.URL + "/me"},
+			expectErr: "expected status-code: 200 received: 401",
+		},
+	}
+
+	for _, tc := range tt {
+		tc.tc.Name = tc.name
+
+		_, err := requester.Request(context.Background(), tc.tc)
+
+		if err != nil {
+			if tc.expectErr == "" {
+				t.Fatalf("%s: Unexpected error\nexpected: <nil>\nreceived: %s", tc.name, err.Error())
+			}
+
+			if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+				t.Fatalf("%s: Expected error does not match\nexpected: %s\nreceived: %s", tc.name, tc.expectErr, err.Error())
+			}
+
+			continue
+		}
+
+		if tc.expectErr != "" {
+			t.Fatalf("%s: Expected to throw error\nexpected: %s\nreceived: <nil>", tc.name, tc.expectErr)
+		}
+	}
+}
+
+func TestCookiesOfRedirects(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "SESSIONID", Value: "s3cr3t", Path: "/", HttpOnly: true})
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "light", Path: "/"})
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark", Path: "/"})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	requester := NewRequester(expectedTimeout, expectedUserAgent)
+	yes, dark := true, "dark"
+
+	res, err := requester.Request(context.Background(), core.TestCase{
+		Name:   "login",
+		URL:    server.URL + "/login",
+		Method: http.MethodPost,
+		Assertions: core.Assertions{Cookies: map[string]core.CookieAssertion{
+			"SESSIONID": {HTTPOnly: &yes},
+			"theme":     {Value: &dark},
+		}},
+		Extract: map[string]core.Extractor{"session": {Cookie: "SESSIONID"}, "theme": {Cookie: "theme"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	// The last cookie set with a name is used, the redirect set theme first.
+	expected := map[string]string{"session": "s3cr3t", "theme": "dark"}
+	if !reflect.DeepEqual(expected, res.Captured) {
+		t.Fatalf("Captured values do not match\nexpected: %+v\nreceived: %+v", expected, res.Captured)
+	}
+}
@@ -0,0 +1,143 @@
+package requester
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"sort"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/requester/internal/jsonpath"
+)
+
+// extract captures named values from the response. Names are processed in
+// sorted order, so errors are reported deterministically.
+func extract(extractors map[string]core.Extractor, res *http.Response, cookies []*http.Cookie, body *responseBody) (map[string]string, error) {
+	names := make([]string, 0, len(extractors))
+	for name := range extractors {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	captured := make(map[string]string, len(extractors))
+
+	for _, name := range names {
+		e := extractors[name]
+
+		var value string
+		var err error
+
+		switch {
+		case countSources(e) != 1:
+			err = errors.New("must set exactly one of json, regex, header or cookie")
+		case e.JSON != "":
+			value, err = extractJSON(e.JSON, body)
+		case e.Regex != "":
+			value, err = extractRegex(e.Regex, e.Group, body.raw)
+		case e.Header != "":
+			value, err = extractHeader(e.Header, res)
+		case e.Cookie != "":
+			value, err = extractCookie(e.Cookie, cookies)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to extract %q: %w", name, err)
+		}
+
+		captured[name] = value
+	}
+
+	return captured, nil
+}
+
+func countSources(e core.Extractor) int {
+	count := 0
+
+	for _, source := range []string{e.JSON, e.Regex, e.Header, e.Cookie} {
+		if source != "" {
+			count++
+		}
+	}
+
+	return count
+}
+
+func extractJSON(expr string, body *responseBody) (string, error) {
+	doc, err := body.JSON()
+	if err != nil {
+		return "", err
+	}
+
+	path, err := jsonpath.Compile(expr)
+	if err != nil {
+		return "", err
+	}
+
+	values := path.Find(doc)
+	if len(values) == 0 {
+		return "", fmt.Errorf("no value found at %s", expr)
+	}
+
+	return stringify(values[0]), nil
+}
+
+// stringify converts a decoded JSON value into the text used in variables.
+// Strings are used as they are, other values are encoded as JSON.
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+
+	encoded, _ := json.Marshal(value)
+
+	return string(encoded)
+}
+
+func extractRegex(expr string, group int, body []byte) (string, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex /%s/: %w", expr, err)
+	}
+
+	if group == 0 && re.NumSubexp() > 0 {
+		group = 1
+	}
+
+	if group < 0 || group > re.NumSubexp() {
+		return "", fmt.Errorf("regex /%s/ does not have group %d", expr, group)
+	}
+
+	matches := re.FindSubmatch(body)
+	if matches == nil {
+		return "", fmt.Errorf("can not match /%s/ in response body", expr)
+	}
+
+	return string(matches[group]), nil
+}
+
+func extractHeader(name string, res *http.Response) (string, error) {
+	canonicalHeaderName := textproto.CanonicalMIMEHeaderKey(name)
+
+	values, ok := res.Header[canonicalHeaderName]
+	if !ok || len(values) == 0 {
+		return "", fmt.Errorf("unable to find response header %s", canonicalHeaderName)
+	}
+
+	return values[0], nil
+}
+
+func extractCookie(name string, cookies []*http.Cookie) (string, error) {
+	cookie := findCookie(cookies, name)
+	if cookie == nil {
+		return "", fmt.Errorf("unable to find cookie %s", name)
+	}
+
+	return cookie.Value, nil
+}
@@ -0,0 +1,153 @@
+package requester
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	mockResBody := `{"data":{"token":"abc","id":42,"roles":["admin"]},"order":"order-1234"}`
+	mockResHeader := http.Header{
+		"X-Request-Id": []string{"req-1"},
+		"Set-Cookie":   []string{"SESSIONID=s3cr3t; Path=/; HttpOnly"},
+	}
+
+	tt := []struct {
+		name          string
+		extract       map[string]core.Extractor
+		mockResBody   string
+		expectCapture map[string]string
+		expectErr     string
+	}{
+		{
+			name: "extract from json, regex, header and cookie",
+			extract: map[string]core.Extractor{
+				"token":     {JSON: "$.data.token"},
+				"id":        {JSON: "data.id"},
+				"roles":     {JSON: "$.data.roles"},
+				"orderId":   {Regex: `order-([0-9]+)`},
+				"order":     {Regex: `order-[0-9]+`},
+				"requestId": {Header: "x-request-id"},
+				"session":   {Cookie: "SESSIONID"},
+			},
+			mockResBody: mockResBody,
+			expectCapture: map[string]string{
+				"token":     "abc",
+				"id":        "42",
+				"roles":     `["admin"]`,
+				"orderId":   "1234",
+				"order":     "order-1234",
+				"requestId": "req-1",
+				"session":   "s3cr3t",
+			},
+		},
+		{
+			name: "extract json numbers exactly",
+			extract: map[string]core.Extractor{
+				"id":    {JSON: "$.id"},
+				"large": {JSON: "$.large"},
+				"price": {JSON: "$.price"},
+			},
+			mockResBody:   `{"id":1234567890123456789,"large":1e21,"price":9.50}`,
+			expectCapture: map[string]string{"id": "1234567890123456789", "large": "1e21", "price": "9.50"},
+		},
+		{
+			name:        "errors when json path does not match",
+			extract:     map[string]core.Extractor{"token": {JSON: "$.data.missing"}},
+			mockResBody: mockResBody,
+			expectErr:   "unable to extract \"token\": no value found at $.data.missing",
+		},
+		{
+			name:        "errors when body is not json",
+			extract:     map[string]core.Extractor{"token": {JSON: "$.data.token"}},
+			mockResBody: "<html>",
+			expectErr:   "unable to extract \"token\": response body is not valid JSON",
+		},
+		{
+			name:        "errors when regex does not match",
+			extract:     map[string]core.Extractor{"orderId": {Regex: "invoice-([0-9]+)"}},
+			mockResBody: mockResBody,
+			expectErr:   "unable to extract \"orderId\": can not match /invoice-([0-9]+)/ in response body",
+		},
+		{
+			name:        "errors when regex group does not exist",
+			extract:     map[string]core.Extractor{"orderId": {Regex: "order-([0-9]+)", Group: 2}},
+			mockResBody: mockResBody,
+			expectErr:   "regex /order-([0-9]+)/ does not have group 2",
+		},
+		{
+			name:        "errors when header is missing",
+			extract:     map[string]core.Extractor{"etag": {Header: "etag"}},
+			mockResBody: mockResBody,
+			expectErr:   "unable to extract \"etag\": unable to find response header Etag",
+		},
+		{
+			name:        "errors when cookie is missing",
+			extract:     map[string]core.Extractor{"csrf": {Cookie: "csrf"}},
+			mockResBody: mockResBody,
+			expectErr:   "unable to extract \"csrf\": unable to find cookie csrf",
+		},
+		{
+			name:        "errors when more than one source is set",
+			extract:     map[string]core.Extractor{"token": {JSON: "$.data.token", Header: "x-token"}},
+			mockResBody: mockResBody,
+			expectErr:   "must set exactly one of json, regex, header or cookie",
+		},
+	}
+
+	for _, item := range tt {
+		t.Run(item.name, func(t *testing.T) {
+			mockClient := newTestClient(func(req *http.Request) *http.Response {
+				return &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(item.mockResBody)),
+					Header:     mockResHeader,
+				}
+			})
+			requester := &Requester{
+				client:    mockClient,
+				userAgent: expectedUserAgent,
+				timeout:   expectedTimeout,
+			}
+
+			res, err := requester.Request(context.Background(), core.TestCase{Name: "test", URL: "example.com", Extract: item.extract})
+
+			if err != nil {
+				if item.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), item.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", item.expectErr, err.Error())
+				}
+
+				if res.Passed {
+					t.Fatal("Expected result to not pass")
+				}
+
+				return
+			}
+
+			if item.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", item.expectErr)
+			}
+
+			if !res.Passed {
+				t.Fatal("Expected result to pass")
+			}
+
+			if !reflect.DeepEqual(item.expectCapture, res.Captured) {
+				t.Fatalf("Captured values do not match\nexpected: %+v\nreceived: %+v", item.expectCapture, res.Captured)
+			}
+		})
+	}
+}
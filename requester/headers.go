@@ -0,0 +1,214 @@
+package requester
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/amad/smoker/core"
+)
+
+// assertHeaders checks the response headers against the header assertions,
+// in the order of the header names.
+func assertHeaders(assertions map[string]core.HeaderAssertion, header http.Header) error {
+	names := make([]string, 0, len(assertions))
+	for name := range assertions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		canonicalName := textproto.CanonicalMIMEHeaderKey(name)
+
+		if err := assertHeader(canonicalName, assertions[name], headerValues(canonicalName, header[canonicalName])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listHeaders are the headers whose values are lists, servers usually send
+// them on one line separated by commas. Other headers, such as Set-Cookie or
+// the ones holding a date or a URL, can have commas in a value.
+var listHeaders = map[string]bool{
+	"Accept":                        true,
+	"Accept-Charset":                true,
+	"Accept-Encoding":               true,
+	"Accept-Language":               true,
+	"Accept-Patch":                  true,
+	"Accept-Post":                   true,
+	"Accept-Ranges":                 true,
+	"Access-Control-Allow-Headers":  true,
+	"Access-Control-Allow-Methods":  true,
+	"Access-Control-Expose-Headers": true,
+	"Allow":                         true,
+	"Cache-Control":                 true,
+	"Connection":                    true,
+	"Content-Encoding":              true,
+	"Content-Language":              true,
+	"Link":                          true,
+	"Pragma":                        true,
+	"Timing-Allow-Origin":           true,
+	"Trailer":                       true,
+	"Transfer-Encoding":             true,
+	"Upgrade":                       true,
+	"Vary":                          true,
+	"Via":                           true,
+	"Warning":                       true,
+}
+
+// headerValues returns the values of a header. The lines of list headers are
+// split at commas, except in quoted strings and in the <URL> of a Link.
+func headerValues(name string, lines []string) []string {
+	if !listHeaders[name] {
+		return lines
+	}
+
+	var values []string
+
+	for _, line := range lines {
+		var quoted, escaped, inURL bool
+
+		start := 0
+
+		for i := 0; i <= len(line); i++ {
+			if i < len(line) {
+				switch c := line[i]; {
+				case escaped:
+					escaped = false
+					continue
+				case quoted && c == '\\':
+					escaped = true
+					continue
+				case c == '"' && !inURL:
+					quoted = !quoted
+					continue
+				case c == '<' && !quoted:
+					inURL = true
+					continue
+				case c == '>' && !quoted:
+					inURL = false
+					continue
+				case c != ',' || quoted || inURL:
+					continue
+				}
+			}
+
+			if value := strings.TrimSpace(line[start:i]); value != "" {
+				values = append(values, value)
+			}
+
+			start = i + 1
+		}
+	}
+
+	return values
+}
+
+func assertHeader(name string, a core.HeaderAssertion, values []string) error {
+	match, err := headerMatcher(a)
+	if err != nil {
+		return fmt.Errorf("invalid assertion on response header %s: %w", name, err)
+	}
+
+	if a.Count != nil && len(values) != *a.Count {
+		return fmt.Errorf("expected response header %s to have %d values received %d", name, *a.Count, len(values))
+	}
+
+	if len(values) == 0 {
+		if a.Count != nil {
+			return nil
+		}
+
+		return fmt.Errorf("unable to find response header %s", name)
+	}
+
+	if match == nil {
+		return nil
+	}
+
+	switch a.Values {
+	case core.HeaderValuesAll:
+		for _, value := range values {
+			if !match(value) {
+				return fmt.Errorf("expected every value of response header %s to %s received %s:%s", name, describeHeaderAssertion(a), name, value)
+			}
+		}
+	case core.HeaderValuesAny, "":
+		for _, value := range values {
+			if match(value) {
+				return nil
+			}
+		}
+
+		if a.Value != "" {
+			return fmt.Errorf("expected response header %s:%s received %s:%s", name, a.Value, name, strings.Join(values, ", "))
+		}
+
+		return fmt.Errorf("expected a value of response header %s to %s received %s:%s", name, describeHeaderAssertion(a), name, strings.Join(values, ", "))
+	}
+
+	return nil
+}
+
+// headerMatcher returns a function checking one header value, or nil when
+// the assertion does not check the values.
+func headerMatcher(a core.HeaderAssertion) (func(value string) bool, error) {
+	if a.Values != "" && a.Values != core.HeaderValuesAny && a.Values != core.HeaderValuesAll {
+		return nil, fmt.Errorf("values must be %s or %s", core.HeaderValuesAny, core.HeaderValuesAll)
+	}
+
+	if a.Value != "" {
+		re, err := regexp.Compile(a.Value)
+
+		return func(value string) bool {
+			return strings.EqualFold(a.Value, value) || err == nil && re.MatchString(value)
+		}, nil
+	}
+
+	if a.Equals == "" && a.Matches == "" {
+		return nil, nil
+	}
+
+	var re *regexp.Regexp
+
+	if a.Matches != "" {
+		var err error
+		if re, err = regexp.Compile(a.Matches); err != nil {
+			return nil, fmt.Errorf("invalid regex /%s/: %w", a.Matches, err)
+		}
+	}
+
+	return func(value string) bool {
+		if a.Equals != "" && !strings.EqualFold(a.Equals, value) {
+			return false
+		}
+
+		return re == nil || re.MatchString(value)
+	}, nil
+}
+
+// describeHeaderAssertion describes the checks of an assertion on header
+// values, such as `equal "gzip"` or `match /^id=/`.
+func describeHeaderAssertion(a core.HeaderAssertion) string {
+	if a.Value != "" {
+		return fmt.Sprintf("equal %q or match /%s/", a.Value, a.Value)
+	}
+
+	var checks []string
+
+	if a.Equals != "" {
+		checks = append(checks, fmt.Sprintf("equal %q", a.Equals))
+	}
+
+	if a.Matches != "" {
+		checks = append(checks, fmt.Sprintf("match /%s/", a.Matches))
+	}
+
+	return strings.Join(checks, " and ")
+}
@@ -0,0 +1,132 @@
+package requester
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestAssertHeaders(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Add("Set-Cookie", "id=1; Secure; HttpOnly")
+	header.Add("Set-Cookie", "theme=dark")
+	header.Set("Vary", "Accept-Encoding, Origin")
+	header.Set("Link", `<https://api.example.com/items?ids=1,2>; rel="next", <https://api.example.com/items?page=9>; rel="last, first"`)
+	header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+
+	count := func(n int) *int { return &n }
+
+	tt := []struct {
+		name       string
+		assertions map[string]core.HeaderAssertion
+		expectErr  string
+	}{
+		{
+			name:       "string matches any value",
+			assertions: map[string]core.HeaderAssertion{"vary": {Value: "origin"}},
+		},
+		{
+			name:       "string matches any value with regex",
+			assertions: map[string]core.HeaderAssertion{"Set-Cookie": {Value: "^theme="}},
+		},
+		{
+			name:       "errors when string matches no value",
+			assertions: map[string]core.HeaderAssertion{"Vary": {Value: "Cookie"}},
+			expectErr:  "expected response header Vary:Cookie received Vary:Accept-Encoding, Origin",
+		},
+		{
+			name:       "equals ignores case",
+			assertions: map[string]core.HeaderAssertion{"Content-Type": {Equals: "Application/JSON"}},
+		},
+		{
+			name:       "equals does not fall back to regex",
+			assertions: map[string]core.HeaderAssertion{"Content-Type": {Equals: "application/.*"}},
+			expectErr:  `expected a value of response header Content-Type to equal "application/.*" received Content-Type:application/json`,
+		},
+		{
+			name:       "matches any value",
+			assertions: map[string]core.HeaderAssertion{"Set-Cookie": {Matches: "HttpOnly", Values: core.HeaderValuesAny}},
+		},
+		{
+			name:       "errors when every value does not match",
+			assertions: map[string]core.HeaderAssertion{"Set-Cookie": {Matches: "HttpOnly", Values: core.HeaderValuesAll}},
+			expectErr:  "expected every value of response header Set-Cookie to match /HttpOnly/ received Set-Cookie:theme=dark",
+		},
+		{
+			name:       "matches every value",
+			assertions: map[string]core.HeaderAssertion{"Set-Cookie": {Matches: "=", Values: core.HeaderValuesAll}},
+		},
+		{
+			name:       "equals and matches",
+			assertions: map[string]core.HeaderAssertion{"Vary": {Equals: "origin", Matches: "^[A-Z]"}},
+		},
+		{
+			name:       "errors when equals and matches do not match the same value",
+			assertions: map[string]core.HeaderAssertion{"Vary": {Equals: "origin", Matches: "Encoding"}},
+			expectErr:  `expected a value of response header Vary to equal "origin" and match /Encoding/ received Vary:Accept-Encoding, Origin`,
+		},
+		{
+			name:       "count values",
+			assertions: map[string]core.HeaderAssertion{"Set-Cookie": {Count: count(2)}, "Content-Type": {Count: count(1)}},
+		},
+		{
+			name:       "comma separated values of list headers",
+			assertions: map[string]core.HeaderAssertion{"Vary": {Equals: "Origin", Count: count(2)}, "Link": {Matches: `^<[^>]+page=9>; rel="last, first"$`, Count: count(2)}},
+		},
+		{
+			name:       "values of other headers are not split",
+			assertions: map[string]core.HeaderAssertion{"Date": {Equals: "Mon, 02 Jan 2006 15:04:05 GMT", Count: count(1)}},
+		},
+		{
+			name:       "errors when count does not match",
+			assertions: map[string]core.HeaderAssertion{"Vary": {Count: count(1)}},
+			expectErr:  "expected response header Vary to have 1 values received 2",
+		},
+		{
+			name:       "zero count of missing header",
+			assertions: map[string]core.HeaderAssertion{"X-Debug-Token": {Count: count(0)}},
+		},
+		{
+			name:       "errors when header not found",
+			assertions: map[string]core.HeaderAssertion{"X-Request-Id": {}},
+			expectErr:  "unable to find response header X-Request-Id",
+		},
+		{
+			name:       "errors on invalid values mode",
+			assertions: map[string]core.HeaderAssertion{"Vary": {Matches: "Origin", Values: "some"}},
+			expectErr:  "invalid assertion on response header Vary: values must be any or all",
+		},
+		{
+			name:       "errors on invalid regex",
+			assertions: map[string]core.HeaderAssertion{"Vary": {Matches: "("}},
+			expectErr:  "invalid assertion on response header Vary: invalid regex /(/",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := assertHeaders(tc.assertions, header)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled JSONPath expression.
+//
+// The supported syntax is a subset of JSONPath: the root ($), child members
+// (.name or ['name']), array indexes ([0], negative indexes count from the
+// end), wildcards (.* or [*]) and recursive descent (..name). The leading $
+// is optional.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+type stepKind int
+
+const (
+	memberStep stepKind = iota
+	indexStep
+	wildcardStep
+)
+
+type step struct {
+	kind      stepKind
+	name      string
+	index     int
+	recursive bool
+}
+
+// Compile parses a JSONPath expression.
+func Compile(expr string) (*Path, error) {
+	p := &Path{expr: expr}
+
+	s := strings.TrimSpace(expr)
+	if strings.HasPrefix(s, "$") {
+		s = s[1:]
+	} else if s != "" && s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+
+	for len(s) > 0 {
+		recursive := false
+
+		switch {
+		case strings.HasPrefix(s, ".."):
+			recursive = true
+			s = s[2:]
+		case s[0] == '.':
+			s = s[1:]
+		case s[0] == '[':
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", expr, s[0])
+		}
+
+		var st step
+		var err error
+
+		if strings.HasPrefix(s, "[") {
+			st, s, err = parseBracket(s)
+		} else {
+			st, s, err = parseMember(s)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %q: %w", expr, err)
+		}
+
+		st.recursive = recursive
+		p.steps = append(p.steps, st)
+	}
+
+	return p, nil
+}
+
+func parseMember(s string) (step, string, error) {
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		end = len(s)
+	}
+
+	name := s[:end]
+	if name == "" {
+		return step{}, s, fmt.Errorf("empty member name")
+	}
+
+	if name == "*" {
+		return step{kind: wildcardStep}, s[end:], nil
+	}
+
+	return step{kind: memberStep, name: name}, s[end:], nil
+}
+
+func parseBracket(s string) (step, string, error) {
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return step{}, s, fmt.Errorf("missing closing bracket")
+	}
+
+	inner := strings.TrimSpace(s[1:end])
+	rest := s[end+1:]
+
+	if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+		return step{kind: memberStep, name: inner[1 : len(inner)-1]}, rest, nil
+	}
+
+	if inner == "*" {
+		return step{kind: wildcardStep}, rest, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return step{}, s, fmt.Errorf("invalid index %q", inner)
+	}
+
+	return step{kind: indexStep, index: index}, rest, nil
+}
+
+// String returns the source expression.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Find returns all values matching the path in a document decoded by encoding/json.
+func (p *Path) Find(doc interface{}) []interface{} {
+	nodes := []interface{}{doc}
+
+	for _, st := range p.steps {
+		var next []interface{}
+
+		for _, node := range nodes {
+			if st.recursive {
+				for _, n := range descendants(node) {
+					next = append(next, st.apply(n)...)
+				}
+			} else {
+				next = append(next, st.apply(node)...)
+			}
+		}
+
+		nodes = next
+	}
+
+	return nodes
+}
+
+func (st step) apply(node interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		switch st.kind {
+		case memberStep:
+			if value, ok := v[st.name]; ok {
+				return []interface{}{value}
+			}
+		case wildcardStep:
+			values := make([]interface{}, 0, len(v))
+			for _, key := range sortedKeys(v) {
+				values = append(values, v[key])
+			}
+
+			return values
+		}
+	case []interface{}:
+		switch st.kind {
+		case indexStep:
+			i := st.index
+			if i < 0 {
+				i += len(v)
+			}
+
+			if i >= 0 && i < len(v) {
+				return []interface{}{v[i]}
+			}
+		case wildcardStep:
+			return append([]interface{}{}, v...)
+		}
+	}
+
+	return nil
+}
+
+// descendants returns the node and all nested values. Object members are
+// visited in key order, as decoded JSON objects do not keep their order.
+func descendants(node interface{}) []interface{} {
+	nodes := []interface{}{node}
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			nodes = append(nodes, descendants(v[key])...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			nodes = append(nodes, descendants(item)...)
+		}
+	}
+
+	return nodes
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
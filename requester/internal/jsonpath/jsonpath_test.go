@@ -0,0 +1,81 @@
+package jsonpath_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/requester/internal/jsonpath"
+)
+
+const doc = `{
+  "status": "ok",
+  "data": {
+    "token": "abc",
+    "user.name": "amad",
+    "items": [
+      {"id": 1, "tags": ["a", "b"]},
+      {"id": 2, "tags": []},
+      {"id": 3}
+    ]
+  }
+}`
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name      string
+		expr      string
+		expectRes []interface{}
+		expectErr string
+	}{
+		{"root", "$", []interface{}{v}, ""},
+		{"member", "$.status", []interface{}{"ok"}, ""},
+		{"nested member", "$.data.token", []interface{}{"abc"}, ""},
+		{"without root", "data.token", []interface{}{"abc"}, ""},
+		{"bracket member", "$['data'][\"user.name\"]", []interface{}{"amad"}, ""},
+		{"index", "$.data.items[1].id", []interface{}{float64(2)}, ""},
+		{"negative index", "$.data.items[-1].id", []interface{}{float64(3)}, ""},
+		{"index out of range", "$.data.items[5]", nil, ""},
+		{"wildcard", "$.data.items[*].id", []interface{}{float64(1), float64(2), float64(3)}, ""},
+		{"recursive descent", "$..id", []interface{}{float64(1), float64(2), float64(3)}, ""},
+		{"recursive descent with index", "$..tags[0]", []interface{}{"a"}, ""},
+		{"missing member", "$.data.missing", nil, ""},
+		{"invalid index", "$.data.items[x]", nil, "invalid index \"x\""},
+		{"missing bracket", "$.data.items[0", nil, "missing closing bracket"},
+		{"empty member", "$.data.", nil, "empty member name"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := jsonpath.Compile(tc.expr)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if res := p.Find(v); !reflect.DeepEqual(tc.expectRes, res) {
+				t.Fatalf("Result does not match\nexpected: %#v\nreceived: %#v", tc.expectRes, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package requester
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/requester/internal/jsonpath"
+)
+
+// assertJSON checks the JSONPath assertions on a decoded response body.
+// Paths are checked in sorted order, so errors are reported deterministically.
+func assertJSON(assertions map[string]core.JSONAssertion, doc interface{}) error {
+	paths := make([]string, 0, len(assertions))
+	for path := range assertions {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, expr := range paths {
+		if err := assertJSONPath(expr, assertions[expr], doc); err != nil {
+			return fmt.Errorf("json %s: %w", expr, err)
+		}
+	}
+
+	return nil
+}
+
+func assertJSONPath(expr string, a core.JSONAssertion, doc interface{}) error {
+	path, err := jsonpath.Compile(expr)
+	if err != nil {
+		return err
+	}
+
+	values := path.Find(doc)
+
+	if a.Exists != nil && !*a.Exists {
+		if len(values) != 0 {
+			return fmt.Errorf("expected to be absent received %s", encode(values[0]))
+		}
+
+		return nil
+	}
+
+	if len(values) == 0 {
+		return fmt.Errorf("no value found")
+	}
+
+	for _, value := range values {
+		if err := assertJSONValue(a, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func assertJSONValue(a core.JSONAssertion, value interface{}) error {
+	if a.Equals != nil {
+		var expected interface{}
+		if err := decodeJSON(a.Equals, &expected); err != nil {
+			return fmt.Errorf("invalid expected value %s: %w", a.Equals, err)
+		}
+
+		if !equalJSON(expected, value) {
+			return fmt.Errorf("expected %s received %s", encode(expected), encode(value))
+		}
+	}
+
+	if a.Type != "" {
+		if actual := typeOf(value); actual != a.Type && !(a.Type == "integer" && isInteger(value)) {
+			return fmt.Errorf("expected type %s received %s %s", a.Type, actual, encode(value))
+		}
+	}
+
+	if a.Length != nil {
+		length, ok := lengthOf(value)
+		if !ok {
+			return fmt.Errorf("expected length %d received %s %s", *a.Length, typeOf(value), encode(value))
+		}
+
+		if length != *a.Length {
+			return fmt.Errorf("expected length %d received %d %s", *a.Length, length, encode(value))
+		}
+	}
+
+	if err := compareNumber(a, value); err != nil {
+		return err
+	}
+
+	if a.Matches != "" {
+		s, ok := value.(string)
+		if !ok {
+			s = encode(value)
+		}
+
+		matched, err := regexp.MatchString(a.Matches, s)
+		if err != nil {
+			return fmt.Errorf("invalid regex /%s/: %w", a.Matches, err)
+		}
+
+		if !matched {
+			return fmt.Errorf("can not match /%s/ received %s", a.Matches, encode(value))
+		}
+	}
+
+	return nil
+}
+
+func compareNumber(a core.JSONAssertion, value interface{}) error {
+	comparisons := []struct {
+		op     string
+		limit  *json.Number
+		passed func(cmp int) bool
+	}{
+		{">", a.Gt, func(cmp int) bool { return cmp > 0 }},
+		{">=", a.Gte, func(cmp int) bool { return cmp >= 0 }},
+		{"<", a.Lt, func(cmp int) bool { return cmp < 0 }},
+		{"<=", a.Lte, func(cmp int) bool { return cmp <= 0 }},
+	}
+
+	for _, c := range comparisons {
+		if c.limit == nil {
+			continue
+		}
+
+		limit, ok := number(*c.limit)
+		if !ok {
+			return fmt.Errorf("invalid number %s %s", c.op, *c.limit)
+		}
+
+		n, ok := number(value)
+		if !ok {
+			return fmt.Errorf("expected a number %s %s received %s %s", c.op, *c.limit, typeOf(value), encode(value))
+		}
+
+		if !c.passed(n.Cmp(limit)) {
+			return fmt.Errorf("expected %s %s received %s", c.op, *c.limit, encode(value))
+		}
+	}
+
+	return nil
+}
+
+// number returns the exact value of a decoded JSON number.
+func number(value interface{}) (*big.Rat, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return nil, false
+	}
+
+	return new(big.Rat).SetString(string(n))
+}
+
+// equalJSON compares decoded JSON values, numbers are equal when they have
+// the same value, such as 1, 1.0 and 1e0.
+func equalJSON(expected, value interface{}) bool {
+	switch e := expected.(type) {
+	case json.Number:
+		x, ok := number(e)
+		y, isNumber := number(value)
+
+		return ok && isNumber && x.Cmp(y) == 0
+	case []interface{}:
+		v, ok := value.([]interface{})
+		if !ok || len(v) != len(e) {
+			return false
+		}
+
+		for i := range e {
+			if !equalJSON(e[i], v[i]) {
+				return false
+			}
+		}
+
+		return true
+	case map[string]interface{}:
+		v, ok := value.(map[string]interface{})
+		if !ok || len(v) != len(e) {
+			return false
+		}
+
+		for key, item := range e {
+			actual, ok := v[key]
+			if !ok || !equalJSON(item, actual) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return reflect.DeepEqual(expected, value)
+	}
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func isInteger(value interface{}) bool {
+	n, ok := number(value)
+	return ok && n.IsInt()
+}
+
+func lengthOf(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case string:
+		return len([]rune(v)), true
+	case []interface{}:
+		return len(v), true
+	case map[string]interface{}:
+		return len(v), true
+	default:
+		return 0, false
+	}
+}
+
+func encode(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(encoded)
+}
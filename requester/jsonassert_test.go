@@ -0,0 +1,192 @@
+package requester
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestJSONAssertions(t *testing.T) {
+	t.Parallel()
+
+	mockResBody := `{"status":"ok","count":3,"price":9.5,"debug":null,"items":[{"id":1},{"id":2}],"user":{"name":"amad","email":"a@example.com"}}`
+
+	parse := func(s string) core.JSONAssertion {
+		var a core.JSONAssertion
+		if err := json.Unmarshal([]byte(s), &a); err != nil {
+			t.Fatal(err)
+		}
+
+		return a
+	}
+
+	tt := []struct {
+		name        string
+		assertions  map[string]string
+		mockResBody string
+		expectErr   string
+	}{
+		{
+			name: "all checks pass",
+			assertions: map[string]string{
+				"$.status":        `"ok"`,
+				"$.count":         `{"type": "integer", "gte": 1, "lt": 10}`,
+				"$.price":         `{"type": "number", "gt": 9, "lte": 9.5}`,
+				"$.debug":         `null`,
+				"$.items":         `{"type": "array", "length": 2}`,
+				"$.items[*].id":   `{"type": "number", "gt": 0}`,
+				"$.user":          `{"equals": {"name": "amad", "email": "a@example.com"}}`,
+				"$.user.email":    `{"matches": "^[^@]+@example\\.com$"}`,
+				"$.user.name":     `{"exists": true, "length": 4}`,
+				"$.user.password": `{"exists": false}`,
+				"$.items[0]":      `{"matches": "\"id\":1"}`,
+				"items[-1].id":    `2`,
+				"$.user['name']":  `{"type": "string"}`,
+				"$..id":           `{"lte": 2}`,
+				"$.items[1]":      `{"type": "object", "length": 1}`,
+			},
+			mockResBody: mockResBody,
+		},
+		{
+			name: "numbers are compared exactly",
+			assertions: map[string]string{
+				"$.id":    `1234567890123456789`,
+				"$.price": `{"equals": 9.5, "gt": 9.49999999999999999999, "lt": 9.50000000000000000001}`,
+				"$.count": `3.0`,
+				"$.list":  `[1, 2e0]`,
+				"$.id2":   `{"type": "integer", "gt": 1234567890123456788}`,
+			},
+			mockResBody: `{"id":1234567890123456789,"id2":1234567890123456789,"price":9.50,"count":3,"list":[1.0,2]}`,
+		},
+		{
+			name:        "errors when value is not equal",
+			assertions:  map[string]string{"$.status": `"fail"`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.status: expected "fail" received "ok"`,
+		},
+		{
+			name:        "errors when large integers are not equal",
+			assertions:  map[string]string{"$.id": `1234567890123456788`},
+			mockResBody: `{"id":1234567890123456789}`,
+			expectErr:   `json $.id: expected 1234567890123456788 received 1234567890123456789`,
+		},
+		{
+			name:        "errors when type does not match",
+			assertions:  map[string]string{"$.status": `{"type": "number"}`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.status: expected type number received string "ok"`,
+		},
+		{
+			name:        "errors when number is not an integer",
+			assertions:  map[string]string{"$.price": `{"type": "integer"}`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.price: expected type integer received number 9.5`,
+		},
+		{
+			name:        "errors when path does not exist",
+			assertions:  map[string]string{"$.missing": `{"exists": true}`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.missing: no value found`,
+		},
+		{
+			name:        "errors when path should be absent",
+			assertions:  map[string]string{"$.debug": `{"exists": false}`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.debug: expected to be absent received null`,
+		},
+		{
+			name:        "errors when length does not match",
+			assertions:  map[string]string{"$.items": `{"length": 3}`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.items: expected length 3 received 2 [{"id":1},{"id":2}]`,
+		},
+		{
+			name:        "errors when value has no length",
+			assertions:  map[string]string{"$.count": `{"length": 3}`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.count: expected length 3 received number 3`,
+		},
+		{
+			name:        "errors when number comparison fails",
+			assertions:  map[string]string{"$.count": `{"gt": 5}`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.count: expected > 5 received 3`,
+		},
+		{
+			name:        "errors when large integer comparison fails",
+			assertions:  map[string]string{"$.id": `{"lte": 1234567890123456788}`},
+			mockResBody: `{"id":1234567890123456789}`,
+			expectErr:   `json $.id: expected <= 1234567890123456788 received 1234567890123456789`,
+		},
+		{
+			name:        "errors when comparing a value that is not a number",
+			assertions:  map[string]string{"$.status": `{"lte": 5}`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.status: expected a number <= 5 received string "ok"`,
+		},
+		{
+			name:        "errors when any value of a wildcard fails",
+			assertions:  map[string]string{"$.items[*].id": `{"lt": 2}`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.items[*].id: expected < 2 received 2`,
+		},
+		{
+			name:        "errors when regex does not match",
+			assertions:  map[string]string{"$.user.email": `{"matches": "@github\\.com$"}`},
+			mockResBody: mockResBody,
+			expectErr:   `json $.user.email: can not match /@github\.com$/ received "a@example.com"`,
+		},
+		{
+			name:        "errors when body is not json",
+			assertions:  map[string]string{"$.status": `"ok"`},
+			mockResBody: "<html>",
+			expectErr:   "response body is not valid JSON",
+		},
+	}
+
+	for _, item := range tt {
+		t.Run(item.name, func(t *testing.T) {
+			mockClient := newTestClient(func(req *http.Request) *http.Response {
+				return &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(item.mockResBody)),
+					Header:     make(http.Header),
+				}
+			})
+			requester := &Requester{
+				client:    mockClient,
+				userAgent: expectedUserAgent,
+				timeout:   expectedTimeout,
+			}
+
+			assertions := make(map[string]core.JSONAssertion)
+			for path, a := range item.assertions {
+				assertions[path] = parse(a)
+			}
+
+			_, err := requester.Request(context.Background(), core.TestCase{Name: "test", URL: "example.com", Assertions: core.Assertions{JSON: assertions}})
+
+			if err != nil {
+				if item.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), item.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", item.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if item.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", item.expectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package requester
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const schemaURL = "testcase.schema.json"
+
+// validateJSONSchema validates a decoded response body against a JSON schema
+// and reports every violation with the location of the invalid value.
+func validateJSONSchema(schema []byte, doc interface{}) error {
+	compiler := jsonschema.NewCompiler()
+
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(schema)); err != nil {
+		return fmt.Errorf("invalid json schema: %w", err)
+	}
+
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return fmt.Errorf("invalid json schema: %w", err)
+	}
+
+	err = compiled.Validate(doc)
+	if err == nil {
+		return nil
+	}
+
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return fmt.Errorf("unable to validate json schema: %w", err)
+	}
+
+	var violations []string
+
+	var collect func(e *jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) != 0 {
+			for _, cause := range e.Causes {
+				collect(cause)
+			}
+
+			return
+		}
+
+		location := e.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+
+		violations = append(violations, fmt.Sprintf("%s: %s", location, e.Message))
+	}
+
+	collect(validationErr)
+
+	// The validator walks objects in map order, sort to report violations deterministically.
+	sort.Strings(violations)
+
+	return fmt.Errorf("response body does not match json schema: %s", strings.Join(violations, "; "))
+}
@@ -0,0 +1,92 @@
+package requester
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	schema := `{
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {
+			"id": {"type": "integer"},
+			"name": {"type": "string"},
+			"items": {"type": "array", "items": {"type": "object", "properties": {"price": {"type": "number", "minimum": 0}}}}
+		}
+	}`
+
+	tt := []struct {
+		name        string
+		schema      string
+		mockResBody string
+		expectErr   string
+	}{
+		{
+			name:        "valid body",
+			schema:      schema,
+			mockResBody: `{"id": 1, "name": "smoker", "items": [{"price": 1.5}]}`,
+		},
+		{
+			name:        "reports every violation",
+			schema:      schema,
+			mockResBody: `{"id": "1", "items": [{"price": -1}, {"price": "free"}]}`,
+			expectErr:   "response body does not match json schema: /: missing properties: 'name'; /id: expected integer, but got string; /items/0/price: must be >= 0 but found -1; /items/1/price: expected number, but got string",
+		},
+		{
+			name:        "errors on invalid schema",
+			schema:      `{"type": 1}`,
+			mockResBody: `{}`,
+			expectErr:   "invalid json schema",
+		},
+		{
+			name:        "errors when body is not json",
+			schema:      schema,
+			mockResBody: `<html>`,
+			expectErr:   "response body is not valid JSON",
+		},
+	}
+
+	for _, item := range tt {
+		t.Run(item.name, func(t *testing.T) {
+			mockClient := newTestClient(func(req *http.Request) *http.Response {
+				return &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(item.mockResBody)),
+					Header:     make(http.Header),
+				}
+			})
+			requester := &Requester{
+				client:    mockClient,
+				userAgent: expectedUserAgent,
+				timeout:   expectedTimeout,
+			}
+
+			_, err := requester.Request(context.Background(), core.TestCase{Name: "test", URL: "example.com", Assertions: core.Assertions{JSONSchema: []byte(item.schema)}})
+
+			if err != nil {
+				if item.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), item.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", item.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if item.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", item.expectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package requester
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// contextSize is the number of bytes shown on each side of an unexpected
+// match in the response body.
+const contextSize = 30
+
+// assertBodyNotContains checks that none of the patterns match the response
+// body. The error shows the first unexpected match with the text around it.
+func assertBodyNotContains(patterns []string, body []byte) error {
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid bodyNotContains pattern /%s/: %w", pattern, err)
+		}
+
+		if loc := re.FindIndex(body); loc != nil {
+			return fmt.Errorf("unexpected match of /%s/ in response body: %s", pattern, excerpt(body, loc[0], loc[1]))
+		}
+	}
+
+	return nil
+}
+
+// excerpt quotes the match body[start:end] between >>> and <<< with up to
+// contextSize bytes around it. Cut text is marked with an ellipsis.
+func excerpt(body []byte, start, end int) string {
+	from := start - contextSize
+	if from < 0 {
+		from = 0
+	}
+
+	for from > 0 && !utf8.RuneStart(body[from]) {
+		from--
+	}
+
+	to := end + contextSize
+	if to > len(body) {
+		to = len(body)
+	}
+
+	for to < len(body) && !utf8.RuneStart(body[to]) {
+		to++
+	}
+
+	var b strings.Builder
+
+	if from > 0 {
+		b.WriteString("...")
+	}
+
+	b.Write(body[from:start])
+	b.WriteString(">>>")
+	b.Write(body[start:end])
+	b.WriteString("<<<")
+	b.Write(body[end:to])
+
+	if to < len(body) {
+		b.WriteString("...")
+	}
+
+	return strconv.Quote(b.String())
+}
+
+// assertHeadersAbsent checks that the response has none of the headers.
+func assertHeadersAbsent(names []string, header http.Header) error {
+	for _, name := range names {
+		canonicalName := textproto.CanonicalMIMEHeaderKey(name)
+
+		if values, found := header[canonicalName]; found {
+			return fmt.Errorf("unexpected response header %s:%s", canonicalName, strings.Join(values, ", "))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package requester
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAssertBodyNotContains(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		patterns  []string
+		body      string
+		expectErr string
+	}{
+		{
+			name:     "no match",
+			patterns: []string{"panic", `"error"`},
+			body:     `{"status": "ok"}`,
+		},
+		{
+			name:      "shows the match",
+			patterns:  []string{`"error"`},
+			body:      `{"error": "boom"}`,
+			expectErr: `unexpected match of /"error"/ in response body: "{>>>\"error\"<<<: \"boom\"}"`,
+		},
+		{
+			name:      "cuts long context",
+			patterns:  []string{"panic"},
+			body:      strings.Repeat("a", 40) + "panic" + strings.Repeat("b", 40),
+			expectErr: `unexpected match of /panic/ in response body: "...` + strings.Repeat("a", 30) + ">>>panic<<<" + strings.Repeat("b", 30) + `..."`,
+		},
+		{
+			name:      "escapes new lines",
+			patterns:  []string{`at \w+\.go:\d+`},
+			body:      "goroutine 1:\nat main.go:12\n",
+			expectErr: `unexpected match of /at \w+\.go:\d+/ in response body: "goroutine 1:\n>>>at main.go:12<<<\n"`,
+		},
+		{
+			name:      "does not cut multi-byte characters",
+			patterns:  []string{"x"},
+			body:      strings.Repeat("😀", 10) + "x",
+			expectErr: `unexpected match of /x/ in response body: "...` + strings.Repeat("😀", 8) + `>>>x<<<"`,
+		},
+		{
+			name:      "errors on invalid pattern",
+			patterns:  []string{"("},
+			body:      "OK",
+			expectErr: "invalid bodyNotContains pattern /(/",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := assertBodyNotContains(tc.patterns, []byte(tc.body))
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestAssertHeadersAbsent(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Add("X-Debug-Token", "abc")
+	header.Add("X-Debug-Token", "def")
+
+	tt := []struct {
+		name      string
+		names     []string
+		expectErr string
+	}{
+		{"absent headers", []string{"X-Powered-By", "Server"}, ""},
+		{"present header", []string{"X-Powered-By", "x-debug-token"}, "unexpected response header X-Debug-Token:abc, def"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := assertHeadersAbsent(tc.names, header)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if err.Error() != tc.expectErr {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+		})
+	}
+}
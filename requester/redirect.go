@@ -0,0 +1,113 @@
+package requester
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/amad/smoker/core"
+)
+
+// hop is a redirect response that was followed.
+type hop struct {
+	url        string
+	statusCode int
+	location   string
+	// resolved is the location resolved against the URL of the request.
+	resolved string
+}
+
+func (h hop) String() string {
+	return fmt.Sprintf("%d %s -> %s", h.statusCode, h.url, h.location)
+}
+
+// redirectChain follows up to max redirects and records them.
+type redirectChain struct {
+	max  core.Redirects
+	hops []hop
+	// cookies are the cookies set by the redirect responses.
+	cookies []*http.Cookie
+}
+
+func newRedirectChain(follow *core.Redirects) *redirectChain {
+	max := core.DefaultRedirects
+	if follow != nil {
+		max = *follow
+	}
+
+	return &redirectChain{max: max}
+}
+
+// checkRedirect is the CheckRedirect function of the client. With max set to
+// 0 the redirect response is returned as the response of the request.
+func (c *redirectChain) checkRedirect(req *http.Request, via []*http.Request) error {
+	if c.max == 0 {
+		return http.ErrUseLastResponse
+	}
+
+	if len(via) > int(c.max) {
+		return fmt.Errorf("stopped after %d redirects", c.max)
+	}
+
+	c.cookies = append(c.cookies, req.Response.Cookies()...)
+
+	c.hops = append(c.hops, hop{
+		url:        via[len(via)-1].URL.String(),
+		statusCode: req.Response.StatusCode,
+		location:   req.Response.Header.Get("Location"),
+		resolved:   req.URL.String(),
+	})
+
+	return nil
+}
+
+// responseCookies returns the cookies set while sending the request, by the
+// redirect responses and then by the final response.
+func (c *redirectChain) responseCookies(res *http.Response) []*http.Cookie {
+	cookies := make([]*http.Cookie, 0, len(c.cookies))
+	cookies = append(cookies, c.cookies...)
+
+	return append(cookies, res.Cookies()...)
+}
+
+// assertRedirects checks the final URL and the redirects that were followed.
+func assertRedirects(a core.Assertions, chain *redirectChain, finalURL string) error {
+	if a.FinalURL != "" && finalURL != a.FinalURL {
+		return fmt.Errorf("expected final url %s received %s", a.FinalURL, finalURL)
+	}
+
+	if a.Redirects == nil {
+		return nil
+	}
+
+	if len(chain.hops) != len(a.Redirects) {
+		return fmt.Errorf("expected %d redirects received %d%s", len(a.Redirects), len(chain.hops), describeHops(chain.hops))
+	}
+
+	for i, expected := range a.Redirects {
+		h := chain.hops[i]
+
+		if len(expected.StatusCode) > 0 && !expected.StatusCode.Match(h.statusCode) {
+			return fmt.Errorf("redirect #%d from %s: expected status-code: %s received: %d", i+1, h.url, expected.StatusCode, h.statusCode)
+		}
+
+		if expected.Location != "" && expected.Location != h.location && expected.Location != h.resolved {
+			return fmt.Errorf("redirect #%d from %s: expected location %s received %s", i+1, h.url, expected.Location, h.location)
+		}
+	}
+
+	return nil
+}
+
+func describeHops(hops []hop) string {
+	if len(hops) == 0 {
+		return ""
+	}
+
+	described := make([]string, len(hops))
+	for i, h := range hops {
+		described[i] = h.String()
+	}
+
+	return ": " + strings.Join(described, ", ")
+}
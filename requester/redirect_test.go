@@ -0,0 +1,153 @@
+package requester
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestRedirects(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login?next=%2Fnew", http.StatusFound)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	follow := func(n core.Redirects) *core.Redirects { return &n }
+
+	tt := []struct {
+		name       string
+		follow     *core.Redirects
+		assertions core.Assertions
+		expectErr  string
+	}{
+		{
+			name: "follows redirects by default",
+			assertions: core.Assertions{
+				FinalURL: server.URL + "/login?next=%2Fnew",
+				Redirects: []core.RedirectAssertion{
+					{StatusCode: core.StatusCodes{"301"}, Location: "/new"},
+					{StatusCode: core.StatusCodes{"3xx"}, Location: server.URL + "/login?next=%2Fnew"},
+				},
+			},
+		},
+		{
+			name:       "does not follow redirects",
+			follow:     follow(0),
+			assertions: core.Assertions{StatusCode: core.StatusCodes{"301"}, Headers: map[string]core.HeaderAssertion{"Location": {Equals: "/new"}}, FinalURL: server.URL + "/old", Redirects: []core.RedirectAssertion{}},
+		},
+		{
+			name:      "errors after the max redirects",
+			follow:    follow(1),
+			expectErr: "stopped after 1 redirects",
+		},
+		{
+			name:       "errors when final url does not match",
+			assertions: core.Assertions{FinalURL: server.URL + "/home"},
+			expectErr:  "expected final url " + server.URL + "/home received " + server.URL + "/login?next=%2Fnew",
+		},
+		{
+			name:       "errors when number of redirects does not match",
+			assertions: core.Assertions{Redirects: []core.RedirectAssertion{{}}},
+			expectErr:  "expected 1 redirects received 2: 301 " + server.URL + "/old -> /new, 302 " + server.URL + "/new -> /login?next=%2Fnew",
+		},
+		{
+			name:       "errors when status code of a redirect does not match",
+			assertions: core.Assertions{Redirects: []core.RedirectAssertion{{StatusCode: core.StatusCodes{"308"}}, {}}},
+			expectErr:  "redirect #1 from " + server.URL + "/old: expected status-code: 308 received: 301",
+		},
+		{
+			name:       "errors when location of a redirect does not match",
+			assertions: core.Assertions{Redirects: []core.RedirectAssertion{{}, {Location: "/login"}}},
+			expectErr:  "redirect #2 from " + server.URL + "/new: expected location /login received /login?next=%2Fnew",
+		},
+	}
+
+	requester := NewRequester(expectedTimeout, expectedUserAgent)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := requester.Request(context.Background(), core.TestCase{Name: tc.name, URL: server.URL + "/old", FollowRedirects: tc.follow, Assertions: tc.assertions})
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestRedirectsWithBody(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/307", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/target", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/308", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/target", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/target", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %d %s", r.Method, r.ContentLength, body)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	requester := NewRequester(expectedTimeout, expectedUserAgent)
+
+	tt := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{"post with 307", http.MethodPost, "/307"},
+		{"put with 308", http.MethodPut, "/308"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := requester.Request(context.Background(), core.TestCase{
+				Name:   tc.name,
+				URL:    server.URL + tc.path,
+				Method: tc.method,
+				Body:   `{"id":1}`,
+				Assertions: core.Assertions{
+					Body:      []string{`^` + tc.method + ` 8 \{"id":1\}$`},
+					FinalURL:  server.URL + "/target",
+					Redirects: []core.RedirectAssertion{{StatusCode: core.StatusCodes{tc.path[1:]}, Location: "/target"}},
+				},
+			})
+
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+		})
+	}
+}
@@ -2,13 +2,20 @@ package requester
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
-	"net/textproto"
+	"net/http/cookiejar"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/amad/smoker/core"
@@ -16,14 +23,14 @@ import (
 )
 
 // NewRequester creates and returns new a Requester.
+// timeout is the default timeout of the requests, test cases can override it.
 func NewRequester(timeout time.Duration, userAgent string) *Requester {
-	client := &http.Client{
-		Timeout: timeout,
-	}
+	client := &http.Client{}
 
 	return &Requester{
 		client:    client,
 		userAgent: userAgent,
+		timeout:   timeout,
 	}
 }
 
@@ -31,17 +38,36 @@ func NewRequester(timeout time.Duration, userAgent string) *Requester {
 type Requester struct {
 	client    *http.Client
 	userAgent string
+	timeout   time.Duration
+	// network holds the network options of every request.
+	network core.Network
+	// sessions holds the cookie jar of each session.
+	sessions   map[string]*cookiejar.Jar
+	sessionsMu sync.Mutex
+	// transports holds the transports of the test cases configuring TLS.
+	transports   map[transportKey]*http.Transport
+	transportsMu sync.Mutex
+	// tokens holds the OAuth2 tokens shared by the test cases.
+	tokens   map[oauth2Key]*oauth2Token
+	tokensMu sync.Mutex
 }
 
 // Request method uses HTTP package to send request and verifies if the
 // response matches test case expectations.
-func (r *Requester) Request(tc core.TestCase) (bool, error) {
+// The request is cancelled with ctx.
+func (r *Requester) Request(ctx context.Context, tc core.TestCase) (core.Result, error) {
+	var result core.Result
+
 	if tc.Name == "" {
-		return false, errors.New("does not have name field")
+		return result, errors.New("does not have name field")
 	}
 
 	if tc.URL == "" {
-		return false, errors.New("does not have url field")
+		return result, errors.New("does not have url field")
+	}
+
+	if tc.Timeout < 0 {
+		return result, errors.New("timeout must be >= 0")
 	}
 
 	if tc.Method != "" {
@@ -50,13 +76,31 @@ func (r *Requester) Request(tc core.TestCase) (bool, error) {
 		tc.Method = http.MethodGet
 	}
 
-	if tc.Assertions.StatusCode == 0 {
-		tc.Assertions.StatusCode = http.StatusOK
+	if len(tc.Assertions.StatusCode) == 0 {
+		tc.Assertions.StatusCode = core.StatusCodes{strconv.Itoa(http.StatusOK)}
+	}
+
+	timeout := r.timeout
+	if tc.Timeout > 0 {
+		timeout = time.Duration(tc.Timeout)
 	}
 
-	req, err := http.NewRequest(tc.Method, tc.URL, nil)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// The body is passed to the request, so it has a length and is sent
+	// again when a 307 or 308 redirect is followed.
+	var reqBody io.Reader
+	if tc.Body != "" {
+		reqBody = bytes.NewReader([]byte(tc.Body))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, tc.Method, tc.URL, reqBody)
 	if err != nil {
-		return false, fmt.Errorf("could not create request: %w", err)
+		return result, fmt.Errorf("could not create request: %w", err)
 	}
 
 	id := uuid.New().String()
@@ -67,56 +111,221 @@ func (r *Requester) Request(tc core.TestCase) (bool, error) {
 		req.Header.Set(name, value)
 	}
 
-	if tc.Body != "" {
-		req.Body = ioutil.NopCloser(bytes.NewReader([]byte(tc.Body)))
+	client, err := r.clientFor(tc)
+	if err != nil {
+		return result, err
+	}
+
+	// OAuth2 tokens are requested before tracing the request, with the
+	// client of the test case but without its cookies and redirect checks.
+	tokenClient := *client
+	tokenClient.Jar = nil
+
+	if err := r.authorize(ctx, &tokenClient, req, tc.Auth); err != nil {
+		return result, err
+	}
+
+	// The request is signed last, once all of its headers are set.
+	if err := sign(req, tc.Sign, []byte(tc.Body), time.Now()); err != nil {
+		return result, err
 	}
 
-	res, err := r.client.Do(req)
+	timing, ctx := newTiming(ctx)
+	req = req.WithContext(ctx)
+
+	redirects := newRedirectChain(tc.FollowRedirects)
+	client.CheckRedirect = redirects.checkRedirect
+
+	res, err := client.Do(req)
 	if err != nil {
-		return false, fmt.Errorf("request failed: %w", err)
+		result.NetworkError = isNetworkError(ctx, err)
+		return result, requestError(ctx, timeout, err)
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode != tc.Assertions.StatusCode {
-		return false, fmt.Errorf("expected status-code: %d received: %d", tc.Assertions.StatusCode, res.StatusCode)
+	result.StatusCode = res.StatusCode
+	result.RemoteAddr = timing.remoteAddr()
+
+	if !tc.Assertions.StatusCode.Match(res.StatusCode) {
+		return result, fmt.Errorf("expected status-code: %s received: %d", tc.Assertions.StatusCode, res.StatusCode)
 	}
 
-	if len(tc.Assertions.Body) != 0 {
-		body, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			return false, fmt.Errorf("unable to read the response body with with error: %w", err)
+	finalURL := req.URL
+	if res.Request != nil {
+		finalURL = res.Request.URL
+	}
+
+	if err := assertRedirects(tc.Assertions, redirects, finalURL.String()); err != nil {
+		return result, err
+	}
+
+	raw, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		result.NetworkError = isNetworkError(ctx, err)
+
+		switch {
+		case errors.Is(ctx.Err(), context.Canceled):
+			return result, fmt.Errorf("request cancelled while reading the response body: %w", ctx.Err())
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return result, fmt.Errorf("timeout of %s exceeded while reading the response body", timeout)
 		}
 
-		bodyStr := string(body)
+		return result, fmt.Errorf("unable to read the response body with with error: %w", err)
+	}
+
+	timing.done()
+
+	if err := timing.assert(tc.Assertions); err != nil {
+		return result, err
+	}
+
+	if err := assertBodySize(tc.Assertions.BodySize, len(raw)); err != nil {
+		return result, err
+	}
+
+	body := &responseBody{raw: raw}
+
+	if len(tc.Assertions.Body) != 0 {
+		bodyStr := string(raw)
 
 		for _, matchInBody := range tc.Assertions.Body {
 			res, err := regexp.MatchString(matchInBody, bodyStr)
 			if err != nil || !res {
-				return false, fmt.Errorf("can not match /%s/ in response body", matchInBody)
+				return result, fmt.Errorf("can not match /%s/ in response body", matchInBody)
 			}
 		}
 	}
 
-	if len(tc.Assertions.Headers) != 0 {
-		for expectedHeaderName, expectedHeaderValue := range tc.Assertions.Headers {
-			canonicalHeaderName := textproto.CanonicalMIMEHeaderKey(expectedHeaderName)
+	if err := assertHeaders(tc.Assertions.Headers, res.Header); err != nil {
+		return result, err
+	}
 
-			headerValue, foundHeader := res.Header[canonicalHeaderName]
+	if err := assertTLS(tc.Assertions.TLS, res.TLS); err != nil {
+		return result, err
+	}
 
-			if !foundHeader {
-				return false, fmt.Errorf("unable to find response header %s", canonicalHeaderName)
-			}
+	cookies := redirects.responseCookies(res)
 
-			if strings.EqualFold(expectedHeaderValue, headerValue[0]) {
-				continue
-			}
+	if err := assertCookies(tc.Assertions.Cookies, cookies); err != nil {
+		return result, err
+	}
 
-			matched, err := regexp.MatchString(expectedHeaderValue, headerValue[0])
-			if err != nil || !matched {
-				return false, fmt.Errorf("expected response header %s:%s received %s:%s", canonicalHeaderName, expectedHeaderValue, canonicalHeaderName, headerValue[0])
-			}
+	if err := assertBodyNotContains(tc.Assertions.BodyNotContains, raw); err != nil {
+		return result, err
+	}
+
+	if err := assertHeadersAbsent(tc.Assertions.HeadersAbsent, res.Header); err != nil {
+		return result, err
+	}
+
+	if len(tc.Assertions.JSON) != 0 {
+		doc, err := body.JSON()
+		if err != nil {
+			return result, err
+		}
+
+		if err := assertJSON(tc.Assertions.JSON, doc); err != nil {
+			return result, err
+		}
+	}
+
+	if len(tc.Assertions.JSONSchema) != 0 {
+		doc, err := body.JSON()
+		if err != nil {
+			return result, err
+		}
+
+		if err := validateJSONSchema(tc.Assertions.JSONSchema, doc); err != nil {
+			return result, err
+		}
+	}
+
+	if len(tc.Extract) != 0 {
+		result.Captured, err = extract(tc.Extract, res, cookies, body)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	result.Passed = true
+
+	return result, nil
+}
+
+// requestError describes a failed request, reporting the timeout when it
+// was exceeded and wrapping the context error when it was cancelled.
+func requestError(ctx context.Context, timeout time.Duration, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("request cancelled: %w", ctx.Err())
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("request failed: timeout of %s exceeded", timeout)
+	}
+
+	return fmt.Errorf("request failed: %w", err)
+}
+
+// isNetworkError checks if a request failed on the network, such as on a
+// refused connection, a closed connection or a timeout. Errors of the test
+// case, such as an unsupported scheme or too many redirects, are not.
+func isNetworkError(ctx context.Context, err error) bool {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return false
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return true
+	}
+
+	// url.Error implements net.Error, check the error it wraps.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr)
+}
+
+// decodeJSON decodes a JSON document keeping numbers as json.Number, so
+// large integers and the text of numbers are not changed by a conversion to
+// float64.
+func decodeJSON(raw []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after top-level value")
+	}
+
+	return nil
+}
+
+// responseBody holds the response body and decodes it as JSON on first use.
+type responseBody struct {
+	raw     []byte
+	doc     interface{}
+	err     error
+	decoded bool
+}
+
+// JSON returns the response body decoded as JSON.
+func (b *responseBody) JSON() (interface{}, error) {
+	if !b.decoded {
+		b.decoded = true
+
+		if err := decodeJSON(b.raw, &b.doc); err != nil {
+			b.err = fmt.Errorf("response body is not valid JSON: %w", err)
 		}
 	}
 
-	return true, nil
+	return b.doc, b.err
 }
@@ -2,6 +2,7 @@ package requester
 
 import (
 	"bytes"
+	"context"
 	"io/ioutil"
 	"net/http"
 	"strings"
@@ -23,8 +24,12 @@ func TestNewRequester(t *testing.T) {
 		t.Fatalf("Expected to set correct user agent %s but received %s", expectedUserAgent, r.userAgent)
 	}
 
-	if r.client.Timeout != expectedTimeout {
-		t.Fatalf("Expected to set timeout %d but received %d", expectedTimeout, r.client.Timeout)
+	if r.timeout != expectedTimeout {
+		t.Fatalf("Expected to set timeout %d but received %d", expectedTimeout, r.timeout)
+	}
+
+	if r.client.Timeout != 0 {
+		t.Fatalf("Expected the client to not set a timeout but received %d", r.client.Timeout)
 	}
 }
 
@@ -48,7 +53,7 @@ func TestRequest(t *testing.T) {
 				Headers: map[string]string{"Content-Type": "application/json"},
 				Body:    "OK",
 				Assertions: core.Assertions{
-					StatusCode: 200,
+					StatusCode: core.StatusCodes{"200"},
 					Body:       []string{"OK"},
 				},
 			},
@@ -91,6 +96,40 @@ func TestRequest(t *testing.T) {
 			mockStatusCode: 500,
 			expectErr:      "expected status-code: 200 received: 500",
 		},
+		{
+			name: "can match one of the status codes",
+			tc: core.TestCase{
+				Name: "test",
+				URL:  "example.com",
+				Assertions: core.Assertions{
+					StatusCode: core.StatusCodes{"200", "204"},
+				},
+			},
+			mockStatusCode: 204,
+		},
+		{
+			name: "can match a class of status codes",
+			tc: core.TestCase{
+				Name: "test",
+				URL:  "example.com",
+				Assertions: core.Assertions{
+					StatusCode: core.StatusCodes{"3xx"},
+				},
+			},
+			mockStatusCode: 302,
+		},
+		{
+			name: "errors when does not match any status code",
+			tc: core.TestCase{
+				Name: "test",
+				URL:  "example.com",
+				Assertions: core.Assertions{
+					StatusCode: core.StatusCodes{"200", "204", "3xx"},
+				},
+			},
+			mockStatusCode: 500,
+			expectErr:      "expected status-code: 200, 204 or 3xx received: 500",
+		},
 		{
 			name: "errors when does not match body",
 			tc: core.TestCase{
@@ -122,7 +161,7 @@ func TestRequest(t *testing.T) {
 				Name: "test",
 				URL:  "example.com",
 				Assertions: core.Assertions{
-					Headers: map[string]string{"Content-Type": "application/json"},
+					Headers: map[string]core.HeaderAssertion{"Content-Type": {Value: "application/json"}},
 				},
 			},
 			mockStatusCode: 200,
@@ -135,19 +174,45 @@ func TestRequest(t *testing.T) {
 				Name: "test",
 				URL:  "example.com",
 				Assertions: core.Assertions{
-					Headers: map[string]string{"Content-Type": "application/json"},
+					Headers: map[string]core.HeaderAssertion{"Content-Type": {Value: "application/json"}},
 				},
 			},
 			mockStatusCode: 200,
 			expectErr:      "unable to find response header Content-Type",
 		},
+		{
+			name: "errors when body contains unexpected match",
+			tc: core.TestCase{
+				Name: "test",
+				URL:  "example.com",
+				Assertions: core.Assertions{
+					BodyNotContains: []string{"panic"},
+				},
+			},
+			mockStatusCode: 200,
+			mockResBody:    "runtime panic",
+			expectErr:      `unexpected match of /panic/ in response body: "runtime >>>panic<<<"`,
+		},
+		{
+			name: "errors when unexpected header found",
+			tc: core.TestCase{
+				Name: "test",
+				URL:  "example.com",
+				Assertions: core.Assertions{
+					HeadersAbsent: []string{"x-debug-token"},
+				},
+			},
+			mockStatusCode: 200,
+			mockResHeader:  map[string]string{"X-Debug-Token": "abc"},
+			expectErr:      "unexpected response header X-Debug-Token:abc",
+		},
 		{
 			name: "can match header",
 			tc: core.TestCase{
 				Name: "test",
 				URL:  "example.com",
 				Assertions: core.Assertions{
-					Headers: map[string]string{"access-control-allow-origin": "*", "content-length": "[0-9]+"},
+					Headers: map[string]core.HeaderAssertion{"access-control-allow-origin": {Value: "*"}, "content-length": {Value: "[0-9]+"}},
 				},
 			},
 			mockStatusCode: 200,
@@ -207,11 +272,12 @@ func TestRequest(t *testing.T) {
 				}
 			})
 			requester := &Requester{
-				mockClient,
-				expectedUserAgent,
+				client:    mockClient,
+				userAgent: expectedUserAgent,
+				timeout:   expectedTimeout,
 			}
 
-			_, err := requester.Request(item.tc)
+			_, err := requester.Request(context.Background(), item.tc)
 
 			if err != nil {
 				if item.expectErr == "" {
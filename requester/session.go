@@ -0,0 +1,49 @@
+package requester
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+
+	"github.com/amad/smoker/core"
+)
+
+// clientFor returns a copy of the client to send the request of a test case.
+// Clients of a session share a cookie jar, requests without a session do not
+// keep cookies.
+func (r *Requester) clientFor(tc core.TestCase) (*http.Client, error) {
+	transport, err := r.transportFor(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	client := *r.client
+
+	if transport != nil {
+		client.Transport = transport
+	}
+
+	if tc.Session != "" {
+		client.Jar = r.jar(tc.Session)
+	}
+
+	return &client, nil
+}
+
+// jar returns the cookie jar of a session.
+func (r *Requester) jar(session string) *cookiejar.Jar {
+	r.sessionsMu.Lock()
+	defer r.sessionsMu.Unlock()
+
+	if r.sessions == nil {
+		r.sessions = make(map[string]*cookiejar.Jar)
+	}
+
+	jar, ok := r.sessions[session]
+	if !ok {
+		// cookiejar.New never fails without a public suffix list.
+		jar, _ = cookiejar.New(nil)
+		r.sessions[session] = jar
+	}
+
+	return jar
+}
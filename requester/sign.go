@@ -0,0 +1,415 @@
+package requester
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amad/smoker/core"
+	"github.com/google/uuid"
+)
+
+// Signer signs a request once all of its headers are set. body is the body
+// of the request and now is the signing time.
+type Signer interface {
+	Sign(req *http.Request, body []byte, now time.Time) error
+}
+
+// signers are the built-in signing methods, by their name in the sign
+// block. Each returns the signer of a signing configuration, or nil when the
+// configuration does not use the method.
+var signers = []struct {
+	name   string
+	signer func(*core.Signing) Signer
+}{
+	{"sigv4", func(s *core.Signing) Signer {
+		if s.SigV4 == nil {
+			return nil
+		}
+
+		return sigV4Signer{s.SigV4}
+	}},
+	{"hmac", func(s *core.Signing) Signer {
+		if s.HMAC == nil {
+			return nil
+		}
+
+		return hmacSigner{s.HMAC}
+	}},
+}
+
+// NewSigner returns the signer of a signing configuration, or nil when it
+// does not sign the request.
+func NewSigner(signing *core.Signing) (Signer, error) {
+	if signing == nil {
+		return nil, nil
+	}
+
+	var (
+		signer Signer
+		set    int
+		names  []string
+	)
+
+	for _, s := range signers {
+		names = append(names, s.name)
+
+		if found := s.signer(signing); found != nil {
+			signer = found
+			set++
+		}
+	}
+
+	if set > 1 {
+		return nil, fmt.Errorf("sign must set only one of %s or %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+	}
+
+	return signer, nil
+}
+
+// sign signs the request once all of its headers are set.
+func sign(req *http.Request, signing *core.Signing, body []byte, now time.Time) error {
+	signer, err := NewSigner(signing)
+	if err != nil || signer == nil {
+		return err
+	}
+
+	return signer.Sign(req, body, now)
+}
+
+// sigV4Signer signs requests with AWS Signature Version 4.
+type sigV4Signer struct {
+	cfg *core.SigV4Signing
+}
+
+func (s sigV4Signer) Sign(req *http.Request, body []byte, now time.Time) error {
+	creds, err := sigV4Credentials()
+	if err != nil {
+		return err
+	}
+
+	return signV4(req, s.cfg, creds, body, now)
+}
+
+// hmacSigner signs requests with an HMAC.
+type hmacSigner struct {
+	cfg *core.HMACSigning
+}
+
+func (s hmacSigner) Sign(req *http.Request, body []byte, now time.Time) error {
+	return signHMAC(req, s.cfg, body, now)
+}
+
+// credentials are AWS credentials.
+type credentials struct {
+	accessKeyID, secretAccessKey, sessionToken string
+}
+
+// sigV4Credentials reads the AWS credentials from the environment.
+func sigV4Credentials() (credentials, error) {
+	creds := credentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if creds.accessKeyID == "" || creds.secretAccessKey == "" {
+		return creds, errors.New("sign sigv4 requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY to be set")
+	}
+
+	return creds, nil
+}
+
+// signV4 signs the request with AWS Signature Version 4. The host, the
+// content type and the X-Amz-* headers are signed.
+func signV4(req *http.Request, cfg *core.SigV4Signing, creds credentials, body []byte, now time.Time) error {
+	region := cfg.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	if region == "" || cfg.Service == "" {
+		return errors.New("sign sigv4 must set region and service")
+	}
+
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := strings.Join([]string{now.Format("20060102"), region, cfg.Service, "aws4_request"}, "/")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	// S3 requires the payload hash header and does not escape paths twice.
+	if cfg.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers := map[string]string{"host": requestHost(req)}
+
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+
+			headers[name] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	if cfg.Service != "s3" {
+		path = uriEncode(path, false)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + creds.secretAccessKey)
+	for _, part := range []string{now.Format("20060102"), region, cfg.Service, "aws4_request"} {
+		key = hmacSum(sha256.New, key, part)
+	}
+
+	signature := hex.EncodeToString(hmacSum(sha256.New, key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.accessKeyID, scope, signedHeaders, signature))
+
+	return nil
+}
+
+// canonicalQuery encodes the query parameters sorted by name, then by value.
+func canonicalQuery(query map[string][]string) string {
+	type param struct{ name, value string }
+
+	var params []param
+
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, param{uriEncode(name, true), uriEncode(value, true)})
+		}
+	}
+
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].name != params[j].name {
+			return params[i].name < params[j].name
+		}
+
+		return params[i].value < params[j].value
+	})
+
+	encoded := make([]string, len(params))
+	for i, p := range params {
+		encoded[i] = p.name + "=" + p.value
+	}
+
+	return strings.Join(encoded, "&")
+}
+
+// uriEncode escapes every byte but the unreserved characters of RFC 3986.
+// Slashes are kept unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// hmacAlgorithms are the hash functions of HMAC signatures.
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// placeholder matches the placeholders of HMAC templates.
+var placeholder = regexp.MustCompile(`\{([a-zA-Z0-9]+(?::[^{}]+)?)\}`)
+
+// signHMAC sets the headers of the signing configuration, then sets the
+// signature of the string built from the template.
+func signHMAC(req *http.Request, cfg *core.HMACSigning, body []byte, now time.Time) error {
+	newHash, ok := hmacAlgorithms[strings.ToLower(cfg.Algorithm)]
+	if cfg.Algorithm == "" {
+		newHash, ok = sha256.New, true
+	}
+
+	if !ok {
+		return errors.New("sign hmac algorithm must be sha1, sha256 or sha512")
+	}
+
+	encode := hex.EncodeToString
+
+	switch strings.ToLower(cfg.Encoding) {
+	case "", "hex":
+	case "base64":
+		encode = base64.StdEncoding.EncodeToString
+	default:
+		return errors.New("sign hmac encoding must be hex or base64")
+	}
+
+	if cfg.Secret == "" || cfg.Template == "" {
+		return errors.New("sign hmac must set secret and template")
+	}
+
+	values := map[string]string{
+		"method":     req.Method,
+		"host":       requestHost(req),
+		"path":       req.URL.EscapedPath(),
+		"query":      req.URL.RawQuery,
+		"body":       string(body),
+		"bodySHA256": sha256Hex(body),
+		"timestamp":  strconv.FormatInt(now.Unix(), 10),
+		"date":       now.UTC().Format(time.RFC3339),
+		"nonce":      uuid.New().String(),
+	}
+
+	if values["path"] == "" {
+		values["path"] = "/"
+	}
+
+	names := make([]string, 0, len(cfg.Headers))
+	for name := range cfg.Headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := fillTemplate(cfg.Headers[name], values, req.Header)
+		if err != nil {
+			return fmt.Errorf("sign hmac header %s: %w", name, err)
+		}
+
+		req.Header.Set(name, value)
+	}
+
+	stringToSign, err := fillTemplate(cfg.Template, values, req.Header)
+	if err != nil {
+		return fmt.Errorf("sign hmac template: %w", err)
+	}
+
+	values["signature"] = encode(hmacSum(newHash, []byte(cfg.Secret), stringToSign))
+
+	valueTemplate := cfg.Value
+	if valueTemplate == "" {
+		valueTemplate = "{signature}"
+	}
+
+	value, err := fillTemplate(valueTemplate, values, req.Header)
+	if err != nil {
+		return fmt.Errorf("sign hmac value: %w", err)
+	}
+
+	header := cfg.Header
+	if header == "" {
+		header = "Authorization"
+	}
+
+	req.Header.Set(header, value)
+
+	return nil
+}
+
+// fillTemplate replaces the placeholders of a template with their values.
+// {header:Name} is replaced with the value of a request header.
+func fillTemplate(template string, values map[string]string, header http.Header) (string, error) {
+	var err error
+
+	filled := placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		name := match[1 : len(match)-1]
+
+		if strings.HasPrefix(name, "header:") {
+			return header.Get(strings.TrimPrefix(name, "header:"))
+		}
+
+		value, ok := values[name]
+		if !ok && err == nil {
+			err = fmt.Errorf("unknown placeholder %s", match)
+		}
+
+		return value
+	})
+
+	return filled, err
+}
+
+// requestHost returns the host the request is sent to.
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+
+	return req.URL.Host
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSum(newHash func() hash.Hash, key []byte, data string) []byte {
+	mac := hmac.New(newHash, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,308 @@
+package requester
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestSignV4(t *testing.T) {
+	t.Parallel()
+
+	// Vectors of the AWS Signature Version 4 test suite.
+	creds := credentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	cfg := &core.SigV4Signing{Region: "us-east-1", Service: "service"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	tt := []struct {
+		name     string
+		method   string
+		url      string
+		header   map[string]string
+		body     string
+		expected string
+	}{
+		{
+			name:     "get vanilla",
+			method:   http.MethodGet,
+			url:      "https://example.amazonaws.com/",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:     "get query sorted by name",
+			method:   http.MethodGet,
+			url:      "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:     "get query sorted by name then value",
+			method:   http.MethodGet,
+			url:      "https://example.amazonaws.com/?id=1&id2=2&a-b=3&a=4",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=ef41923b885b3b861c3d8b2bce02358babdae20cbba21795e2a2ef90263df5b2",
+		},
+		{
+			name:     "post form body",
+			method:   http.MethodPost,
+			url:      "https://example.amazonaws.com/",
+			header:   map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			body:     "Param1=value1",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			req.Header.Set("User-Agent", expectedUserAgent)
+			for name, value := range tc.header {
+				req.Header.Set(name, value)
+			}
+
+			if err := signV4(req, cfg, creds, []byte(tc.body), now); err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			if req.Header.Get("X-Amz-Date") != "20150830T123600Z" {
+				t.Fatalf("Header X-Amz-Date does not match\nexpected: 20150830T123600Z\nreceived: %s", req.Header.Get("X-Amz-Date"))
+			}
+
+			if auth := req.Header.Get("Authorization"); auth != tc.expected {
+				t.Fatalf("Header Authorization does not match\nexpected: %s\nreceived: %s", tc.expected, auth)
+			}
+		})
+	}
+}
+
+func TestSignHMAC(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1600000000, 0)
+	signature := func(s string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(s))
+
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tt := []struct {
+		name      string
+		cfg       core.HMACSigning
+		header    string
+		expected  string
+		expectErr string
+	}{
+		{
+			name:     "default header and value",
+			cfg:      core.HMACSigning{Secret: "secret", Template: "{method} {path}?{query}"},
+			header:   "Authorization",
+			expected: signature("POST /orders?id=1"),
+		},
+		{
+			name: "headers and value templates",
+			cfg: core.HMACSigning{
+				Secret:   "secret",
+				Template: "{method}\n{host}\n{header:X-Timestamp}\n{bodySHA256}",
+				Header:   "X-Signature",
+				Value:    "HMAC-SHA256 key=smoker, signature={signature}",
+				Headers:  map[string]string{"X-Timestamp": "{timestamp}"},
+			},
+			header:   "X-Signature",
+			expected: "HMAC-SHA256 key=smoker, signature=" + signature("POST\nexample.com\n1600000000\n"+sha256Hex([]byte(`{"id":1}`))),
+		},
+		{
+			name:     "base64 sha1",
+			cfg:      core.HMACSigning{Secret: "secret", Template: "{body}", Algorithm: "sha1", Encoding: "base64"},
+			header:   "Authorization",
+			expected: "pkq9ASkZdrNd69hwrROjDJQ0ODE=",
+		},
+		{
+			name:      "errors when algorithm is not supported",
+			cfg:       core.HMACSigning{Secret: "secret", Template: "{body}", Algorithm: "md5"},
+			expectErr: "sign hmac algorithm must be sha1, sha256 or sha512",
+		},
+		{
+			name:      "errors when encoding is not supported",
+			cfg:       core.HMACSigning{Secret: "secret", Template: "{body}", Encoding: "base32"},
+			expectErr: "sign hmac encoding must be hex or base64",
+		},
+		{
+			name:      "errors when secret is not set",
+			cfg:       core.HMACSigning{Template: "{body}"},
+			expectErr: "sign hmac must set secret and template",
+		},
+		{
+			name:      "errors when placeholder is unknown",
+			cfg:       core.HMACSigning{Secret: "secret", Template: "{method} {url}"},
+			expectErr: "sign hmac template: unknown placeholder {url}",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "https://example.com/orders?id=1", nil)
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			err = signHMAC(req, &tc.cfg, []byte(`{"id":1}`), now)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if err.Error() != tc.expectErr {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if value := req.Header.Get(tc.header); value != tc.expected {
+				t.Fatalf("Header %s does not match\nexpected: %s\nreceived: %s", tc.header, tc.expected, value)
+			}
+		})
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("Content-Type")))
+
+		if r.Header.Get("X-Signature") != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	requester := NewRequester(expectedTimeout, expectedUserAgent)
+	signing := &core.Signing{HMAC: &core.HMACSigning{Secret: "secret", Template: "{method} {path} {header:Content-Type}", Header: "X-Signature"}}
+
+	tt := []struct {
+		name      string
+		sign      *core.Signing
+		expectErr string
+	}{
+		{
+			name: "signs the final request",
+			sign: signing,
+		},
+		{
+			name:      "empty signing does not sign",
+			sign:      &core.Signing{},
+			expectErr: "expected status-code: 200 received: 401",
+		},
+		{
+			name:      "errors when more than one kind is set",
+			sign:      &core.Signing{SigV4: &core.SigV4Signing{}, HMAC: signing.HMAC},
+			expectErr: "sign must set only one of sigv4 or hmac",
+		},
+	}
+
+	for _, tc := range tt {
+		_, err := requester.Request(context.Background(), core.TestCase{
+			Name:    tc.name,
+			URL:     server.URL + "/orders",
+			Method:  "post",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    `{"id":1}`,
+			Sign:    tc.sign,
+		})
+
+		if err != nil {
+			if tc.expectErr == "" {
+				t.Fatalf("%s: Unexpected error\nexpected: <nil>\nreceived: %s", tc.name, err.Error())
+			}
+
+			if !strings.Contains(err.Error(), tc.expectErr) {
+				t.Fatalf("%s: Expected error does not match\nexpected: %s\nreceived: %s", tc.name, tc.expectErr, err.Error())
+			}
+
+			continue
+		}
+
+		if tc.expectErr != "" {
+			t.Fatalf("%s: Expected to throw error\nexpected: %s\nreceived: <nil>", tc.name, tc.expectErr)
+		}
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		sign      *core.Signing
+		expected  Signer
+		expectErr string
+	}{
+		{
+			name: "no signing",
+		},
+		{
+			name: "empty signing",
+			sign: &core.Signing{},
+		},
+		{
+			name:     "sigv4",
+			sign:     &core.Signing{SigV4: &core.SigV4Signing{Service: "execute-api"}},
+			expected: sigV4Signer{&core.SigV4Signing{Service: "execute-api"}},
+		},
+		{
+			name:     "hmac",
+			sign:     &core.Signing{HMAC: &core.HMACSigning{Secret: "secret"}},
+			expected: hmacSigner{&core.HMACSigning{Secret: "secret"}},
+		},
+		{
+			name:      "more than one kind",
+			sign:      &core.Signing{SigV4: &core.SigV4Signing{}, HMAC: &core.HMACSigning{}},
+			expectErr: "sign must set only one of sigv4 or hmac",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := NewSigner(tc.sign)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if err.Error() != tc.expectErr {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if !reflect.DeepEqual(signer, tc.expected) {
+				t.Fatalf("Signer does not match\nexpected: %#v\nreceived: %#v", tc.expected, signer)
+			}
+		})
+	}
+}
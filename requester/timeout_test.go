@@ -0,0 +1,160 @@
+package requester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-header" {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		if r.URL.Path == "/slow-body" {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	tt := []struct {
+		name           string
+		path           string
+		defaultTimeout time.Duration
+		timeout        core.Duration
+		expectErr      string
+	}{
+		{"within the default timeout", "/slow-header", time.Second, 0, ""},
+		{"exceeds the default timeout", "/slow-header", 50 * time.Millisecond, 0, "request failed: timeout of 50ms exceeded"},
+		{"test case timeout overrides the default", "/slow-header", 50 * time.Millisecond, core.Duration(time.Second), ""},
+		{"exceeds the test case timeout", "/slow-header", time.Second, core.Duration(50 * time.Millisecond), "request failed: timeout of 50ms exceeded"},
+		{"exceeds the timeout while reading the body", "/slow-body", time.Second, core.Duration(50 * time.Millisecond), "timeout of 50ms exceeded while reading the response body"},
+		{"negative timeout", "/", time.Second, core.Duration(-time.Second), "timeout must be >= 0"},
+	}
+
+	for _, item := range tt {
+		t.Run(item.name, func(t *testing.T) {
+			requester := NewRequester(item.defaultTimeout, expectedUserAgent)
+
+			_, err := requester.Request(context.Background(), core.TestCase{Name: "test", URL: server.URL + item.path, Timeout: item.timeout})
+
+			if err != nil {
+				if item.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), item.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", item.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if item.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", item.expectErr)
+			}
+		})
+	}
+}
+
+func TestRequestCancelled(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	requester := NewRequester(10*time.Second, expectedUserAgent)
+
+	start := time.Now()
+	res, err := requester.Request(ctx, core.TestCase{Name: "test", URL: server.URL})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %v", context.Canceled, err)
+	}
+
+	if res.NetworkError {
+		t.Fatal("Expected a cancelled request to not be a network error")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected request to stop when cancelled, took %s", elapsed)
+	}
+}
+
+func TestNetworkError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case "/hang-up":
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		case "/partial-body":
+			conn, buf, _ := w.(http.Hijacker).Hijack()
+			buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\npartial")
+			buf.Flush()
+			conn.Close()
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tt := []struct {
+		name     string
+		tc       core.TestCase
+		expected bool
+	}{
+		{"connection refused", core.TestCase{URL: closed.URL}, true},
+		{"connection closed", core.TestCase{URL: server.URL + "/hang-up"}, true},
+		{"connection closed while reading the body", core.TestCase{URL: server.URL + "/partial-body"}, true},
+		{"timeout exceeded", core.TestCase{URL: server.URL + "/slow", Timeout: core.Duration(50 * time.Millisecond)}, true},
+		{"missing url", core.TestCase{}, false},
+		{"unsupported scheme", core.TestCase{URL: "example.com"}, false},
+		{"too many redirects", core.TestCase{URL: server.URL + "/loop"}, false},
+		{"empty bearer token", core.TestCase{URL: server.URL, Auth: &core.Auth{Bearer: &core.BearerAuth{}}}, false},
+		{"invalid sign block", core.TestCase{URL: server.URL, Sign: &core.Signing{HMAC: &core.HMACSigning{}}}, false},
+	}
+
+	requester := NewRequester(expectedTimeout, expectedUserAgent)
+
+	for _, item := range tt {
+		t.Run(item.name, func(t *testing.T) {
+			item.tc.Name = item.name
+
+			res, err := requester.Request(context.Background(), item.tc)
+			if err == nil {
+				t.Fatal("Expected to throw error\nexpected: request error\nreceived: <nil>")
+			}
+
+			if res.NetworkError != item.expected {
+				t.Fatalf("Network error does not match\nexpected: %t\nreceived: %t (%s)", item.expected, res.NetworkError, err.Error())
+			}
+		})
+	}
+}
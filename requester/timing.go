@@ -0,0 +1,111 @@
+package requester
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+// timing measures the phases of a request with httptrace.
+type timing struct {
+	mu           sync.Mutex
+	start        time.Time
+	connectStart time.Time
+	connect      time.Duration
+	firstByte    time.Duration
+	total        time.Duration
+	// addr is the remote address of the connection of the request.
+	addr string
+}
+
+func newTiming(ctx context.Context) (*timing, context.Context) {
+	t := &timing{start: time.Now()}
+
+	trace := &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			if err == nil && t.connect == 0 {
+				t.connect = time.Since(t.connectStart)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			t.addr = info.Conn.RemoteAddr().String()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			t.firstByte = time.Since(t.start)
+		},
+	}
+
+	return t, httptrace.WithClientTrace(ctx, trace)
+}
+
+// remoteAddr returns the address the request was sent to.
+func (t *timing) remoteAddr() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.addr
+}
+
+// done records the total duration once the response body is read.
+func (t *timing) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total = time.Since(t.start)
+}
+
+func (t *timing) assert(a core.Assertions) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limits := []struct {
+		name     string
+		limit    core.Duration
+		measured time.Duration
+	}{
+		{"duration", a.MaxDuration, t.total},
+		{"time to first byte", a.MaxTimeToFirstByte, t.firstByte},
+		{"connect time", a.MaxConnectTime, t.connect},
+	}
+
+	for _, l := range limits {
+		if l.limit > 0 && l.measured > time.Duration(l.limit) {
+			return fmt.Errorf("expected %s <= %s received %s", l.name, time.Duration(l.limit), l.measured.Round(time.Millisecond))
+		}
+	}
+
+	return nil
+}
+
+func assertBodySize(size core.BodySize, received int) error {
+	if size.Min != nil && int64(received) < *size.Min {
+		return fmt.Errorf("expected body size >= %d bytes received %d bytes", *size.Min, received)
+	}
+
+	if size.Max != nil && int64(received) > *size.Max {
+		return fmt.Errorf("expected body size <= %d bytes received %d bytes", *size.Max, received)
+	}
+
+	return nil
+}
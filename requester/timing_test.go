@@ -0,0 +1,101 @@
+package requester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestTimingAndBodySizeAssertions(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-header" {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		if r.URL.Path == "/slow-body" {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		fmt.Fprint(w, "0123456789")
+	}))
+	defer server.Close()
+
+	size := func(n int64) *int64 { return &n }
+
+	tt := []struct {
+		name       string
+		path       string
+		assertions core.Assertions
+		expectErr  string
+	}{
+		{
+			name:       "within limits",
+			path:       "/",
+			assertions: core.Assertions{MaxDuration: core.Duration(time.Second), MaxTimeToFirstByte: core.Duration(time.Second), MaxConnectTime: core.Duration(time.Second), BodySize: core.BodySize{Min: size(10), Max: size(10)}},
+		},
+		{
+			name:       "errors when response is too slow",
+			path:       "/slow-body",
+			assertions: core.Assertions{MaxDuration: core.Duration(50 * time.Millisecond)},
+			expectErr:  "expected duration <= 50ms received ",
+		},
+		{
+			name:       "errors when first byte is too slow",
+			path:       "/slow-header",
+			assertions: core.Assertions{MaxTimeToFirstByte: core.Duration(50 * time.Millisecond)},
+			expectErr:  "expected time to first byte <= 50ms received ",
+		},
+		{
+			name:       "first byte is not delayed by a slow body",
+			path:       "/slow-body",
+			assertions: core.Assertions{MaxTimeToFirstByte: core.Duration(90 * time.Millisecond)},
+		},
+		{
+			name:       "errors when body is too small",
+			path:       "/",
+			assertions: core.Assertions{BodySize: core.BodySize{Min: size(11)}},
+			expectErr:  "expected body size >= 11 bytes received 10 bytes",
+		},
+		{
+			name:       "errors when body is too large",
+			path:       "/",
+			assertions: core.Assertions{BodySize: core.BodySize{Max: size(9)}},
+			expectErr:  "expected body size <= 9 bytes received 10 bytes",
+		},
+	}
+
+	for _, item := range tt {
+		t.Run(item.name, func(t *testing.T) {
+			requester := NewRequester(time.Second, expectedUserAgent)
+
+			_, err := requester.Request(context.Background(), core.TestCase{Name: "test", URL: server.URL + item.path, Assertions: item.assertions})
+
+			if err != nil {
+				if item.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), item.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", item.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if item.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", item.expectErr)
+			}
+		})
+	}
+}
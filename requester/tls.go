@@ -0,0 +1,114 @@
+package requester
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+// tlsVersions maps the TLS versions of a test case to their constant.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func newTLSConfig(key transportKey) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         key.serverName,
+		InsecureSkipVerify: key.insecureSkipVerify,
+	}
+
+	if key.minVersion != "" {
+		version, ok := tlsVersions[key.minVersion]
+		if !ok {
+			return nil, errors.New("tls minVersion must be 1.0, 1.1, 1.2 or 1.3")
+		}
+
+		config.MinVersion = version
+	}
+
+	if key.ca != "" {
+		pem, err := ioutil.ReadFile(key.ca)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read tls ca: %w", err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in tls ca %s", key.ca)
+		}
+	}
+
+	if key.cert != "" || key.key != "" {
+		if key.cert == "" || key.key == "" {
+			return nil, errors.New("tls cert and key must be set together")
+		}
+
+		cert, err := tls.LoadX509KeyPair(key.cert, key.key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load tls client certificate: %w", err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// assertTLS checks the certificate of the server.
+func assertTLS(a core.TLSAssertion, state *tls.ConnectionState) error {
+	if a.MinValidDays == nil && len(a.SANs) == 0 {
+		return nil
+	}
+
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return errors.New("expected a TLS connection to check the server certificate")
+	}
+
+	cert := state.PeerCertificates[0]
+
+	if a.MinValidDays != nil {
+		validDays := int(cert.NotAfter.Sub(time.Now()).Hours() / 24)
+
+		if cert.NotAfter.Before(time.Now().AddDate(0, 0, *a.MinValidDays)) {
+			return fmt.Errorf("expected certificate to be valid for at least %d days received expiry on %s in %d days", *a.MinValidDays, cert.NotAfter.UTC().Format("2006-01-02"), validDays)
+		}
+	}
+
+	for _, san := range a.SANs {
+		if !hasSAN(cert, san) {
+			return fmt.Errorf("expected certificate to be valid for %s received %v", san, sans(cert))
+		}
+	}
+
+	return nil
+}
+
+// hasSAN checks if a certificate lists a name or is valid for it, such as
+// a.example.com with a *.example.com certificate.
+func hasSAN(cert *x509.Certificate, san string) bool {
+	for _, name := range sans(cert) {
+		if name == san {
+			return true
+		}
+	}
+
+	return cert.VerifyHostname(san) == nil
+}
+
+func sans(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+
+	return names
+}
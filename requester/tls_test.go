@@ -0,0 +1,291 @@
+package requester
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+// newCertificate creates a self-signed certificate and writes it and its
+// key as PEM files in dir.
+func newCertificate(t *testing.T, dir string, name string, template *x509.Certificate) (*x509.Certificate, tls.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.SerialNumber = big.NewInt(1)
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+
+	if template.NotAfter.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+		template.NotAfter = time.Now().Add(24 * time.Hour)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, pair
+}
+
+func TestTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "smoker-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, serverCert := newCertificate(t, dir, "server", &x509.Certificate{DNSNames: []string{"internal.test"}, IPAddresses: []net.IP{net.ParseIP("127.0.0.1")}})
+	client, _ := newCertificate(t, dir, "client", &x509.Certificate{DNSNames: []string{"client.test"}})
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(client)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}, MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	mtlsServer := httptest.NewUnstartedServer(handler)
+	mtlsServer.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	mtlsServer.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}, ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	mtlsServer.StartTLS()
+	defer mtlsServer.Close()
+
+	yes := true
+	ca := filepath.Join(dir, "server.pem")
+
+	tt := []struct {
+		name      string
+		url       string
+		tls       core.TLS
+		expectErr string
+	}{
+		{
+			name:      "errors without the ca",
+			url:       server.URL,
+			expectErr: "request failed",
+		},
+		{
+			name: "trusts the ca",
+			url:  server.URL,
+			tls:  core.TLS{CA: ca},
+		},
+		{
+			name: "skips verify",
+			url:  server.URL,
+			tls:  core.TLS{InsecureSkipVerify: &yes},
+		},
+		{
+			name: "verifies the server name",
+			url:  server.URL,
+			tls:  core.TLS{CA: ca, ServerName: "internal.test"},
+		},
+		{
+			name:      "errors when the server name does not match",
+			url:       server.URL,
+			tls:       core.TLS{CA: ca, ServerName: "other.test"},
+			expectErr: "request failed",
+		},
+		{
+			name:      "errors below the min version",
+			url:       server.URL,
+			tls:       core.TLS{CA: ca, MinVersion: "1.3"},
+			expectErr: "request failed",
+		},
+		{
+			name:      "errors on invalid min version",
+			url:       server.URL,
+			tls:       core.TLS{MinVersion: "2"},
+			expectErr: "tls minVersion must be 1.0, 1.1, 1.2 or 1.3",
+		},
+		{
+			name:      "errors without client certificate",
+			url:       mtlsServer.URL,
+			tls:       core.TLS{CA: ca},
+			expectErr: "request failed",
+		},
+		{
+			name: "sends the client certificate",
+			url:  mtlsServer.URL,
+			tls:  core.TLS{CA: ca, Cert: filepath.Join(dir, "client.pem"), Key: filepath.Join(dir, "client.key")},
+		},
+		{
+			name:      "errors when key is missing",
+			url:       mtlsServer.URL,
+			tls:       core.TLS{CA: ca, Cert: filepath.Join(dir, "client.pem")},
+			expectErr: "tls cert and key must be set together",
+		},
+		{
+			name:      "errors when ca is missing",
+			url:       server.URL,
+			tls:       core.TLS{CA: filepath.Join(dir, "missing.pem")},
+			expectErr: "unable to read tls ca",
+		},
+		{
+			name:      "errors when ca has no certificate",
+			url:       server.URL,
+			tls:       core.TLS{CA: filepath.Join(dir, "client.key")},
+			expectErr: "no certificate found in tls ca",
+		},
+	}
+
+	requester := NewRequester(expectedTimeout, expectedUserAgent)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := requester.Request(context.Background(), core.TestCase{Name: tc.name, URL: tc.url, TLS: tc.tls})
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestAssertTLS(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "smoker-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cert, _ := newCertificate(t, dir, "server", &x509.Certificate{
+		DNSNames:    []string{"api.example.com", "*.example.org"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(10*24*time.Hour + time.Hour),
+	})
+
+	state := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	days := func(n int) *int { return &n }
+
+	tt := []struct {
+		name      string
+		assertion core.TLSAssertion
+		state     *tls.ConnectionState
+		expectErr string
+	}{
+		{
+			name:      "valid long enough",
+			assertion: core.TLSAssertion{MinValidDays: days(10)},
+			state:     state,
+		},
+		{
+			name:      "errors when expiring soon",
+			assertion: core.TLSAssertion{MinValidDays: days(30)},
+			state:     state,
+			expectErr: "expected certificate to be valid for at least 30 days received expiry on " + cert.NotAfter.UTC().Format("2006-01-02") + " in 10 days",
+		},
+		{
+			name:      "matches sans",
+			assertion: core.TLSAssertion{SANs: []string{"api.example.com", "*.example.org", "www.example.org", "10.0.0.1"}},
+			state:     state,
+		},
+		{
+			name:      "errors when san does not match",
+			assertion: core.TLSAssertion{SANs: []string{"example.com"}},
+			state:     state,
+			expectErr: "expected certificate to be valid for example.com received [api.example.com *.example.org 10.0.0.1]",
+		},
+		{
+			name:      "errors without tls connection",
+			assertion: core.TLSAssertion{SANs: []string{"example.com"}},
+			expectErr: "expected a TLS connection to check the server certificate",
+		},
+		{
+			name: "no assertion without tls connection",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := assertTLS(tc.assertion, tc.state)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package requester
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+// transportKey identifies the transport of a test case. Test cases with the
+// same key share a transport and its idle connections.
+type transportKey struct {
+	ca, cert, key, serverName, minVersion string
+	insecureSkipVerify                    bool
+	proxy, resolve, connectTo             string
+}
+
+// newTransportKey merges the network options of a test case with the ones
+// of the run. Mappings of the test case take precedence.
+func (r *Requester) newTransportKey(tc core.TestCase) transportKey {
+	proxy := tc.Proxy
+	if proxy == "" {
+		proxy = r.network.Proxy
+	}
+
+	return transportKey{
+		ca:                 tc.TLS.CA,
+		cert:               tc.TLS.Cert,
+		key:                tc.TLS.Key,
+		serverName:         tc.TLS.ServerName,
+		minVersion:         tc.TLS.MinVersion,
+		insecureSkipVerify: tc.TLS.InsecureSkipVerify != nil && *tc.TLS.InsecureSkipVerify,
+		proxy:              proxy,
+		resolve:            strings.Join(append(append([]string{}, tc.Resolve...), r.network.Resolve...), ","),
+		connectTo:          strings.Join(append(append([]string{}, tc.ConnectTo...), r.network.ConnectTo...), ","),
+	}
+}
+
+// SetNetwork sets the proxy and the address mappings of every request. Test
+// cases can set their own proxy and add mappings.
+func (r *Requester) SetNetwork(n core.Network) error {
+	if _, err := parseProxy(n.Proxy); err != nil {
+		return err
+	}
+
+	if _, err := parseMappings(strings.Join(n.Resolve, ","), strings.Join(n.ConnectTo, ",")); err != nil {
+		return err
+	}
+
+	r.network = n
+
+	return nil
+}
+
+// transportFor returns the transport sending the request of a test case, or
+// nil when the test case uses the transport of the client.
+func (r *Requester) transportFor(tc core.TestCase) (http.RoundTripper, error) {
+	key := r.newTransportKey(tc)
+	if key == (transportKey{}) {
+		return nil, nil
+	}
+
+	r.transportsMu.Lock()
+	defer r.transportsMu.Unlock()
+
+	if transport, ok := r.transports[key]; ok {
+		return transport, nil
+	}
+
+	transport, err := newTransport(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.transports == nil {
+		r.transports = make(map[transportKey]*http.Transport)
+	}
+
+	r.transports[key] = transport
+
+	return transport, nil
+}
+
+func newTransport(key transportKey) (*http.Transport, error) {
+	config, err := newTLSConfig(key)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := parseProxy(key.proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := parseMappings(key.resolve, key.connectTo)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if len(m.resolve) > 0 || len(m.connectTo) > 0 {
+		dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: dialKeepAlive}
+
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, m.apply(addr))
+		}
+	}
+
+	return transport, nil
+}
+
+// Dialer options of http.DefaultTransport.
+const (
+	dialTimeout   = 30 * time.Second
+	dialKeepAlive = 30 * time.Second
+)
+
+func parseProxy(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: must be a URL such as http://proxy:3128", proxy)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: scheme must be http, https or socks5", proxy)
+	}
+
+	return u, nil
+}
+
+// mapping replaces the host and port of an address. Empty fields match any
+// host or port, and keep the host or port of the address.
+type mapping struct {
+	host, port     string
+	toHost, toPort string
+}
+
+type mappings struct {
+	resolve   []mapping
+	connectTo []mapping
+}
+
+// parseMappings parses comma separated resolve and connectTo mappings.
+func parseMappings(resolve string, connectTo string) (*mappings, error) {
+	m := &mappings{}
+
+	for _, rule := range split(resolve) {
+		parts := strings.SplitN(rule, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || !validPort(parts[1]) || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid resolve %q: must be host:port:address", rule)
+		}
+
+		host := parts[0]
+		if host == "*" {
+			host = ""
+		}
+
+		m.resolve = append(m.resolve, mapping{host: host, port: parts[1], toHost: strings.Trim(parts[2], "[]")})
+	}
+
+	for _, rule := range split(connectTo) {
+		parts := strings.SplitN(rule, ":", 3)
+		if len(parts) != 3 || !validPort(parts[1]) {
+			return nil, fmt.Errorf("invalid connectTo %q: must be host:port:connect-host:connect-port", rule)
+		}
+
+		i := strings.LastIndex(parts[2], ":")
+		if i < 0 || !validPort(parts[2][i+1:]) {
+			return nil, fmt.Errorf("invalid connectTo %q: must be host:port:connect-host:connect-port", rule)
+		}
+
+		m.connectTo = append(m.connectTo, mapping{host: parts[0], port: parts[1], toHost: strings.Trim(parts[2][:i], "[]"), toPort: parts[2][i+1:]})
+	}
+
+	return m, nil
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+func validPort(port string) bool {
+	if port == "" {
+		return true
+	}
+
+	n, err := strconv.Atoi(port)
+
+	return err == nil && n > 0 && n < 65536
+}
+
+// apply returns the address to connect to instead of addr. The first
+// matching connectTo mapping is applied, then the first matching resolve
+// mapping, like curl does.
+func (m *mappings) apply(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	host, port = match(m.connectTo, host, port)
+	host, port = match(m.resolve, host, port)
+
+	return net.JoinHostPort(host, port)
+}
+
+func match(rules []mapping, host string, port string) (string, string) {
+	for _, rule := range rules {
+		if (rule.host == "" || strings.EqualFold(rule.host, host)) && (rule.port == "" || rule.port == port) {
+			if rule.toHost != "" {
+				host = rule.toHost
+			}
+
+			if rule.toPort != "" {
+				port = rule.toPort
+			}
+
+			break
+		}
+	}
+
+	return host, port
+}
@@ -0,0 +1,192 @@
+package requester
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestMappings(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		resolve   []string
+		connectTo []string
+		addr      string
+		expect    string
+		expectErr string
+	}{
+		{name: "no mapping", addr: "example.com:443", expect: "example.com:443"},
+		{name: "resolve", resolve: []string{"example.com:443:10.0.0.5"}, addr: "example.com:443", expect: "10.0.0.5:443"},
+		{name: "resolve ignores case", resolve: []string{"Example.com:443:10.0.0.5"}, addr: "example.com:443", expect: "10.0.0.5:443"},
+		{name: "resolve other port", resolve: []string{"example.com:443:10.0.0.5"}, addr: "example.com:80", expect: "example.com:80"},
+		{name: "resolve any host", resolve: []string{"*:443:10.0.0.5"}, addr: "example.org:443", expect: "10.0.0.5:443"},
+		{name: "resolve ipv6", resolve: []string{"example.com:443:[::1]"}, addr: "example.com:443", expect: "[::1]:443"},
+		{name: "first resolve wins", resolve: []string{"example.com:443:10.0.0.5", "example.com:443:10.0.0.6"}, addr: "example.com:443", expect: "10.0.0.5:443"},
+		{name: "connect to", connectTo: []string{"example.com:443:canary.example.com:8443"}, addr: "example.com:443", expect: "canary.example.com:8443"},
+		{name: "connect to any host and port", connectTo: []string{"::10.0.0.5:"}, addr: "example.com:443", expect: "10.0.0.5:443"},
+		{name: "connect to then resolve", connectTo: []string{"example.com:443:canary.example.com:"}, resolve: []string{"canary.example.com:443:10.0.0.7"}, addr: "example.com:443", expect: "10.0.0.7:443"},
+		{name: "errors on invalid resolve", resolve: []string{"example.com:10.0.0.5"}, expectErr: `invalid resolve "example.com:10.0.0.5": must be host:port:address`},
+		{name: "errors on invalid port", resolve: []string{"example.com:https:10.0.0.5"}, expectErr: `invalid resolve "example.com:https:10.0.0.5": must be host:port:address`},
+		{name: "errors on invalid connect to", connectTo: []string{"example.com:443:canary"}, expectErr: `invalid connectTo "example.com:443:canary": must be host:port:connect-host:connect-port`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := parseMappings(strings.Join(tc.resolve, ","), strings.Join(tc.connectTo, ","))
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if err.Error() != tc.expectErr {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if addr := m.apply(tc.addr); addr != tc.expect {
+				t.Fatalf("Address does not match\nexpected: %s\nreceived: %s", tc.expect, addr)
+			}
+		})
+	}
+}
+
+func TestSetNetwork(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		network   core.Network
+		expectErr string
+	}{
+		{"empty", core.Network{}, ""},
+		{"valid", core.Network{Proxy: "socks5://localhost:1080", Resolve: []string{"example.com:443:10.0.0.5"}, ConnectTo: []string{"example.com:443::8443"}}, ""},
+		{"invalid proxy", core.Network{Proxy: "localhost:3128"}, `invalid proxy "localhost:3128": must be a URL such as http://proxy:3128`},
+		{"unsupported proxy", core.Network{Proxy: "ftp://proxy:21"}, `invalid proxy "ftp://proxy:21": scheme must be http, https or socks5`},
+		{"proxy without host", core.Network{Proxy: "http://"}, `invalid proxy "http://": must be a URL such as http://proxy:3128`},
+		{"invalid resolve", core.Network{Resolve: []string{"example.com"}}, `invalid resolve "example.com": must be host:port:address`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := NewRequester(expectedTimeout, expectedUserAgent).SetNetwork(tc.network)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if err.Error() != tc.expectErr {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestNetworkRouting(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "host=%s", r.Host)
+	}))
+	defer server.Close()
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "proxied=%s", r.URL)
+	}))
+	defer proxy.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	serverAddr := server.Listener.Addr().String()
+	proxyAddr := proxy.Listener.Addr().String()
+
+	tt := []struct {
+		name       string
+		run        core.Network
+		tc         core.TestCase
+		body       string
+		remoteAddr string
+	}{
+		{
+			name:       "resolves the host",
+			tc:         core.TestCase{URL: "http://api.example.test:" + port + "/", Network: core.Network{Resolve: []string{"api.example.test:" + port + ":127.0.0.1"}}},
+			body:       "^host=api.example.test:" + port + "$",
+			remoteAddr: serverAddr,
+		},
+		{
+			name:       "connects to another address",
+			tc:         core.TestCase{URL: "http://api.example.test/", Network: core.Network{ConnectTo: []string{"api.example.test:80:127.0.0.1:" + port}}},
+			body:       "^host=api.example.test$",
+			remoteAddr: serverAddr,
+		},
+		{
+			name:       "uses the mappings of the run",
+			run:        core.Network{ConnectTo: []string{"api.example.test:80:127.0.0.1:" + port}},
+			tc:         core.TestCase{URL: "http://api.example.test/"},
+			body:       "^host=api.example.test$",
+			remoteAddr: serverAddr,
+		},
+		{
+			name:       "test case mappings take precedence",
+			run:        core.Network{ConnectTo: []string{"api.example.test:80:127.0.0.1:1"}},
+			tc:         core.TestCase{URL: "http://api.example.test/", Network: core.Network{ConnectTo: []string{"api.example.test:80:127.0.0.1:" + port}}},
+			body:       "^host=api.example.test$",
+			remoteAddr: serverAddr,
+		},
+		{
+			name:       "sends through the proxy",
+			tc:         core.TestCase{URL: "http://api.example.test/users", Network: core.Network{Proxy: proxy.URL}},
+			body:       "^proxied=http://api.example.test/users$",
+			remoteAddr: proxyAddr,
+		},
+		{
+			name:       "sends through the proxy of the run",
+			run:        core.Network{Proxy: proxy.URL},
+			tc:         core.TestCase{URL: "http://api.example.test/users"},
+			body:       "^proxied=http://api.example.test/users$",
+			remoteAddr: proxyAddr,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			requester := NewRequester(expectedTimeout, expectedUserAgent)
+			if err := requester.SetNetwork(tc.run); err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			tc.tc.Name = tc.name
+			tc.tc.Assertions.Body = []string{tc.body}
+
+			res, err := requester.Request(context.Background(), tc.tc)
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			if res.RemoteAddr != tc.remoteAddr {
+				t.Fatalf("Remote address does not match\nexpected: %s\nreceived: %s", tc.remoteAddr, res.RemoteAddr)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/amad/smoker/core"
+)
+
+// Filter selects the test cases of a run. An empty filter selects all test cases.
+type Filter struct {
+	// Tags selects test cases having at least one of the tags.
+	Tags []string
+	// ExcludeTags skips test cases having any of the tags.
+	ExcludeTags []string
+	// Run is a regular expression selecting test cases by name.
+	Run string
+	// ForbidOnly fails the run when a test case is marked only, so a marker
+	// left in a testsuite does not silently skip the other test cases.
+	ForbidOnly bool
+}
+
+type filter struct {
+	tags        map[string]bool
+	excludeTags map[string]bool
+	run         *regexp.Regexp
+	forbidOnly  bool
+}
+
+func (f Filter) compile() (*filter, error) {
+	compiled := &filter{tags: set(f.Tags), excludeTags: set(f.ExcludeTags), forbidOnly: f.ForbidOnly}
+
+	if f.Run != "" {
+		re, err := regexp.Compile(f.Run)
+		if err != nil {
+			return nil, fmt.Errorf("invalid run pattern /%s/: %w", f.Run, err)
+		}
+
+		compiled.run = re
+	}
+
+	return compiled, nil
+}
+
+func set(values []string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+
+	return m
+}
+
+func (f *filter) match(tc core.TestCase) bool {
+	if f.run != nil && !f.run.MatchString(tc.Name) {
+		return false
+	}
+
+	selected := len(f.tags) == 0
+
+	for _, tag := range tc.Tags {
+		if f.excludeTags[tag] {
+			return false
+		}
+
+		if f.tags[tag] {
+			selected = true
+		}
+	}
+
+	return selected
+}
+
+// selectJobs returns the jobs matching the filter and the jobs they depend
+// on, so a selected test case does not fail because its dependency was
+// filtered out. When test cases are marked only, the other test cases are
+// filtered out. The order of jobs is kept.
+func selectJobs(jobs []*job, f *filter) ([]*job, error) {
+	var only []string
+
+	for _, j := range jobs {
+		if j.tc.Only {
+			only = append(only, fmt.Sprintf("%q", j.tc.Name))
+		}
+	}
+
+	if f.forbidOnly && len(only) > 0 {
+		return nil, fmt.Errorf("testcases are marked only: %s", strings.Join(only, ", "))
+	}
+
+	needed := make(map[*job]bool, len(jobs))
+
+	var need func(j *job)
+	need = func(j *job) {
+		if needed[j] {
+			return
+		}
+
+		needed[j] = true
+
+		for _, dep := range j.deps {
+			need(dep)
+		}
+	}
+
+	for _, j := range jobs {
+		if f.match(j.tc) && (len(only) == 0 || j.tc.Only) {
+			need(j)
+		}
+	}
+
+	selected := make([]*job, 0, len(needed))
+
+	for _, j := range jobs {
+		if needed[j] {
+			selected = append(selected, j)
+		}
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,194 @@
+package runner
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []core.TestCase{
+		{Name: "login", Tags: []string{"critical"}},
+		{Name: "profile", Tags: []string{"critical"}, DependsOn: []string{"setup"}},
+		{Name: "report", Tags: []string{"slow"}},
+		{Name: "search", Tags: []string{"critical", "slow"}},
+		{Name: "setup"},
+	}
+
+	tt := []struct {
+		name        string
+		filter      Filter
+		expectNames []string
+		expectErr   string
+	}{
+		{"empty filter selects all test cases", Filter{}, []string{"login", "report", "search", "setup", "profile"}, ""},
+		{"tags", Filter{Tags: []string{"slow"}}, []string{"report", "search"}, ""},
+		{"exclude tags", Filter{ExcludeTags: []string{"slow"}}, []string{"login", "setup", "profile"}, ""},
+		{"tags and exclude tags", Filter{Tags: []string{"critical"}, ExcludeTags: []string{"slow"}}, []string{"login", "setup", "profile"}, ""},
+		{"run pattern", Filter{Run: "^(login|re)"}, []string{"login", "report"}, ""},
+		{"run pattern and tags", Filter{Run: "s", Tags: []string{"slow"}}, []string{"search"}, ""},
+		{"includes dependencies", Filter{Run: "profile"}, []string{"setup", "profile"}, ""},
+		{"invalid run pattern", Filter{Run: "("}, nil, "invalid run pattern /(/"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := tc.filter.compile()
+
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %v", tc.expectErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			jobs, err := plan(tests)
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			selected, err := selectJobs(jobs, f)
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			var names []string
+			for _, j := range selected {
+				names = append(names, j.tc.Name)
+			}
+
+			if !reflect.DeepEqual(names, tc.expectNames) {
+				t.Fatalf("Selected test cases do not match\nexpected: %v\nreceived: %v", tc.expectNames, names)
+			}
+		})
+	}
+}
+
+func TestSelectJobsWithOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := []core.TestCase{
+		{Name: "login"},
+		{Name: "profile", Only: true, DependsOn: []string{"login"}},
+		{Name: "report", Only: true, Tags: []string{"slow"}},
+		{Name: "search"},
+	}
+
+	tt := []struct {
+		name        string
+		filter      Filter
+		expectNames []string
+		expectErr   string
+	}{
+		{"runs the test cases marked only", Filter{}, []string{"login", "profile", "report"}, ""},
+		{"combines only with filters", Filter{ExcludeTags: []string{"slow"}}, []string{"login", "profile"}, ""},
+		{"forbid only", Filter{ForbidOnly: true}, nil, "testcases are marked only: \"profile\", \"report\""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			f, _ := tc.filter.compile()
+
+			jobs, err := plan(tests)
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			selected, err := selectJobs(jobs, f)
+
+			if tc.expectErr != "" {
+				if err == nil || err.Error() != tc.expectErr {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %v", tc.expectErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			var names []string
+			for _, j := range selected {
+				names = append(names, j.tc.Name)
+			}
+
+			if !reflect.DeepEqual(names, tc.expectNames) {
+				t.Fatalf("Selected test cases do not match\nexpected: %v\nreceived: %v", tc.expectNames, names)
+			}
+		})
+	}
+}
+
+func TestRunnerWithSkippedTestcases(t *testing.T) {
+	var buffer bytes.Buffer
+
+	runner := NewRunner(2, time.Second, true, &buffer, &buffer)
+
+	ok, err := runner.Run(&capturingRequester{}, &core.Testsuite{Tests: []core.TestCase{
+		{Name: "login", Skip: "login is broken", Extract: map[string]core.Extractor{"token": {JSON: "$.token"}}},
+		{Name: "profile", Headers: map[string]string{"Authorization": "Bearer ${token}"}},
+		{Name: "fail", Skip: "known failure"},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	if !ok {
+		t.Fatalf("Expected skipped test cases to not fail the run\nreceived: %s", buffer.String())
+	}
+
+	for _, expected := range []string{
+		"SKIP: testcase #1 \"login\" login is broken",
+		"SKIP: testcase #2 \"profile\" depends on testcase #1 \"login\" which was skipped",
+		"SKIP: testcase #3 \"fail\" known failure",
+	} {
+		if !strings.Contains(buffer.String(), expected) {
+			t.Fatalf("Output does not contain: %s\nreceived: %s", expected, buffer.String())
+		}
+	}
+}
+
+func TestRunnerWithFilter(t *testing.T) {
+	var buffer bytes.Buffer
+
+	runner := NewRunner(1, time.Second, false, &buffer, &buffer)
+
+	if err := runner.SetFilter(Filter{Tags: []string{"critical"}}); err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	testsuite := &core.Testsuite{Tests: []core.TestCase{{Name: "a", Tags: []string{"critical"}}, {Name: "fail"}, {Name: "b"}}}
+
+	ok, err := runner.Run(&testRequester{}, testsuite)
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	if !ok || len(runner.reports) != 1 || runner.reports[0].Name != "a" {
+		t.Fatalf("Expected to only run testcase \"a\"\nreceived: %s", buffer.String())
+	}
+
+	if !strings.Contains(buffer.String(), "Tests:   1 total (2 filtered out)") {
+		t.Fatalf("Output does not show the filtered test cases\nreceived: %s", buffer.String())
+	}
+
+	runner = newTestRunner(1, 1, false)
+	_ = runner.SetFilter(Filter{Tags: []string{"nightly"}})
+
+	_, err = runner.Run(&testRequester{}, testsuite)
+	if err == nil || err.Error() != "no testcase matches the filters" {
+		t.Fatalf("Expected error does not match\nexpected: no testcase matches the filters\nreceived: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/vars"
+)
+
+// job is a test case scheduled by the runner.
+type job struct {
+	// index is the position of the test case in the testsuite, starting at 1.
+	index int
+	tc    core.TestCase
+	deps  []*job
+	// producers are the jobs capturing the variables referenced by the test case.
+	producers map[string]*job
+	retry     *retrier
+	// done is closed when the test case has finished, passed, skipped and
+	// captured are set before.
+	done     chan struct{}
+	passed   bool
+	skipped  bool
+	captured map[string]string
+}
+
+// plan creates jobs for all test cases and orders them so every job comes
+// after the jobs it depends on. Otherwise the testsuite order is kept.
+//
+// A test case depends on the test cases listed in its dependsOn field and on
+// the test case extracting a variable it references. When several test cases
+// extract the same variable, the closest one before it in the testsuite is used.
+func plan(tests []core.TestCase) ([]*job, error) {
+	jobs := make([]*job, len(tests))
+	byName := make(map[string][]*job)
+	producers := make(map[string][]*job)
+
+	for i, tc := range tests {
+		jobs[i] = &job{index: i + 1, tc: tc, done: make(chan struct{})}
+		byName[tc.Name] = append(byName[tc.Name], jobs[i])
+
+		for name := range tc.Extract {
+			producers[name] = append(producers[name], jobs[i])
+		}
+	}
+
+	for _, j := range jobs {
+		for _, name := range j.tc.DependsOn {
+			switch deps := byName[name]; len(deps) {
+			case 0:
+				return nil, fmt.Errorf("testcase %q depends on unknown testcase %q", j.tc.Name, name)
+			case 1:
+				j.addDep(deps[0])
+			default:
+				return nil, fmt.Errorf("testcase %q depends on testcase %q which is defined more than once", j.tc.Name, name)
+			}
+		}
+
+		for _, name := range vars.References(j.tc) {
+			if producer := closestProducer(j, producers[name]); producer != nil {
+				j.addDep(producer)
+
+				if j.producers == nil {
+					j.producers = make(map[string]*job)
+				}
+
+				j.producers[name] = producer
+			}
+		}
+	}
+
+	return sortJobs(jobs)
+}
+
+func (j *job) addDep(dep *job) {
+	for _, d := range j.deps {
+		if d == dep {
+			return
+		}
+	}
+
+	j.deps = append(j.deps, dep)
+}
+
+// lookup resolves a variable captured by the producer of the reference.
+func (j *job) lookup(name string) (string, bool) {
+	producer, ok := j.producers[name]
+	if !ok {
+		return "", false
+	}
+
+	value, ok := producer.captured[name]
+
+	return value, ok
+}
+
+func closestProducer(j *job, producers []*job) *job {
+	var closest *job
+
+	for _, p := range producers {
+		if p == j {
+			continue
+		}
+
+		if p.index < j.index || closest == nil {
+			closest = p
+		}
+	}
+
+	return closest
+}
+
+// sortJobs returns jobs in dependency order, preferring the testsuite order.
+func sortJobs(jobs []*job) ([]*job, error) {
+	sorted := make([]*job, 0, len(jobs))
+	placed := make(map[*job]bool, len(jobs))
+
+	for len(sorted) < len(jobs) {
+		progress := false
+
+		for _, j := range jobs {
+			if placed[j] || !j.ready(placed) {
+				continue
+			}
+
+			sorted = append(sorted, j)
+			placed[j] = true
+			progress = true
+
+			break
+		}
+
+		if !progress {
+			var names []string
+
+			for _, j := range jobs {
+				if !placed[j] {
+					names = append(names, fmt.Sprintf("%q", j.tc.Name))
+				}
+			}
+
+			return nil, fmt.Errorf("dependency cycle between testcases %s", strings.Join(names, ", "))
+		}
+	}
+
+	return sorted, nil
+}
+
+func (j *job) ready(placed map[*job]bool) bool {
+	for _, dep := range j.deps {
+		if !placed[dep] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/core"
+)
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name        string
+		tests       []core.TestCase
+		expectOrder []int
+		expectDeps  map[int][]int
+		expectErr   string
+	}{
+		{
+			"keeps testsuite order without dependencies",
+			[]core.TestCase{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+			[]int{1, 2, 3},
+			map[int][]int{},
+			"",
+		},
+		{
+			"moves dependencies first",
+			[]core.TestCase{{Name: "a", DependsOn: []string{"c"}}, {Name: "b"}, {Name: "c"}},
+			[]int{2, 3, 1},
+			map[int][]int{1: {3}},
+			"",
+		},
+		{
+			"depends on the test case extracting a referenced variable",
+			[]core.TestCase{
+				{Name: "profile", URL: "https://example.com/me", Headers: map[string]string{"Authorization": "Bearer ${token}"}},
+				{Name: "login", Extract: map[string]core.Extractor{"token": {JSON: "$.token"}}},
+			},
+			[]int{2, 1},
+			map[int][]int{1: {2}},
+			"",
+		},
+		{
+			"uses the closest previous test case extracting a variable",
+			[]core.TestCase{
+				{Name: "login", Extract: map[string]core.Extractor{"token": {JSON: "$.token"}}},
+				{Name: "refresh", Body: "${token}", Extract: map[string]core.Extractor{"token": {JSON: "$.token"}}},
+				{Name: "profile", URL: "https://example.com/${token}"},
+			},
+			[]int{1, 2, 3},
+			map[int][]int{2: {1}, 3: {2}},
+			"",
+		},
+		{
+			"errors on unknown dependency",
+			[]core.TestCase{{Name: "a", DependsOn: []string{"missing"}}},
+			nil,
+			nil,
+			"testcase \"a\" depends on unknown testcase \"missing\"",
+		},
+		{
+			"errors on ambiguous dependency",
+			[]core.TestCase{{Name: "a", DependsOn: []string{"b"}}, {Name: "b"}, {Name: "b"}},
+			nil,
+			nil,
+			"testcase \"a\" depends on testcase \"b\" which is defined more than once",
+		},
+		{
+			"errors on dependency cycle",
+			[]core.TestCase{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}, {Name: "c"}},
+			nil,
+			nil,
+			"dependency cycle between testcases \"a\", \"b\"",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			jobs, err := plan(tc.tests)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			var order []int
+			deps := make(map[int][]int)
+
+			for _, j := range jobs {
+				order = append(order, j.index)
+
+				for _, dep := range j.deps {
+					deps[j.index] = append(deps[j.index], dep.index)
+				}
+			}
+
+			if !reflect.DeepEqual(tc.expectOrder, order) {
+				t.Fatalf("Order does not match\nexpected: %v\nreceived: %v", tc.expectOrder, order)
+			}
+
+			if !reflect.DeepEqual(tc.expectDeps, deps) {
+				t.Fatalf("Dependencies do not match\nexpected: %v\nreceived: %v", tc.expectDeps, deps)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+// Backoff strategies of a retry policy.
+const (
+	// BackoffConstant waits the retry delay before every retry.
+	BackoffConstant = "constant"
+	// BackoffExponential doubles the delay after every retry.
+	BackoffExponential = "exponential"
+	// BackoffExponentialJitter waits a random delay between zero and the exponential delay.
+	BackoffExponentialJitter = "exponential-jitter"
+)
+
+// RetryOnNetwork is the retryable outcome of a request that was sent but did
+// not receive a response.
+const RetryOnNetwork = "network"
+
+// Defaults used when neither the run nor the test case set a retry option.
+var (
+	defaultRetryDelay = time.Second
+	defaultRetryOn    = []string{RetryOnNetwork, "5xx"}
+)
+
+// maxBackoffShift limits how many times the exponential delay is doubled.
+const maxBackoffShift = 16
+
+// jitter returns a random number in [0.0,1.0), it is replaced in tests.
+// The source is seeded, so runs do not wait the same delays.
+var jitter = newJitter(time.Now().UnixNano())
+
+// newJitter returns a jitter function of a seeded source, safe for
+// concurrent use.
+func newJitter(seed int64) func() float64 {
+	var mu sync.Mutex
+	r := rand.New(rand.NewSource(seed))
+
+	return func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return r.Float64()
+	}
+}
+
+// ValidRetryOutcome checks if s is a retryable outcome: network, a status
+// code such as 503 or a class of status codes such as 5xx.
+func ValidRetryOutcome(s string) bool {
+	return s == RetryOnNetwork || core.ValidStatusCode(s)
+}
+
+// retrier decides if and when a failed test case is sent again.
+type retrier struct {
+	retries int
+	delay   time.Duration
+	backoff string
+	retryOn []string
+}
+
+// newRetrier merges the retry policy of a test case with the one of the run.
+func newRetrier(run core.RetryPolicy, tc core.RetryPolicy) (*retrier, error) {
+	rt := &retrier{delay: defaultRetryDelay, backoff: BackoffConstant, retryOn: defaultRetryOn}
+
+	for _, p := range []core.RetryPolicy{run, tc} {
+		if p.Retries != nil {
+			rt.retries = *p.Retries
+		}
+
+		if p.RetryDelay != nil {
+			rt.delay = time.Duration(*p.RetryDelay)
+		}
+
+		if p.Backoff != "" {
+			rt.backoff = p.Backoff
+		}
+
+		if p.RetryOn != nil {
+			rt.retryOn = p.RetryOn
+		}
+	}
+
+	if rt.retries < 0 {
+		return nil, fmt.Errorf("retries must be >= 0")
+	}
+
+	if rt.delay < 0 {
+		return nil, fmt.Errorf("retryDelay must be >= 0")
+	}
+
+	switch rt.backoff {
+	case BackoffConstant, BackoffExponential, BackoffExponentialJitter:
+	default:
+		return nil, fmt.Errorf("backoff must be %s, %s or %s", BackoffConstant, BackoffExponential, BackoffExponentialJitter)
+	}
+
+	for _, outcome := range rt.retryOn {
+		if !ValidRetryOutcome(outcome) {
+			return nil, fmt.Errorf("retryOn does not support %q", outcome)
+		}
+	}
+
+	return rt, nil
+}
+
+// retryable checks if the outcome of a failed attempt is worth retrying.
+func (rt *retrier) retryable(res core.Result) bool {
+	if res.NetworkError {
+		for _, outcome := range rt.retryOn {
+			if outcome == RetryOnNetwork {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return core.StatusCodes(rt.retryOn).Match(res.StatusCode)
+}
+
+// wait returns the delay before the given retry, starting at 1.
+func (rt *retrier) wait(retry int) time.Duration {
+	if rt.backoff == BackoffConstant {
+		return rt.delay
+	}
+
+	shift := retry - 1
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+
+	delay := rt.delay << uint(shift)
+
+	if rt.backoff == BackoffExponentialJitter {
+		delay = time.Duration(jitter() * float64(delay))
+	}
+
+	return delay
+}
@@ -0,0 +1,238 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amad/smoker/core"
+)
+
+func intPtr(i int) *int { return &i }
+
+func durationPtr(d time.Duration) *core.Duration {
+	cd := core.Duration(d)
+	return &cd
+}
+
+func TestNewRetrier(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name      string
+		run       core.RetryPolicy
+		tc        core.RetryPolicy
+		expected  retrier
+		expectErr string
+	}{
+		{
+			"defaults",
+			core.RetryPolicy{},
+			core.RetryPolicy{},
+			retrier{0, time.Second, BackoffConstant, []string{"network", "5xx"}},
+			"",
+		},
+		{
+			"run policy",
+			core.RetryPolicy{Retries: intPtr(3), RetryDelay: durationPtr(time.Millisecond), Backoff: BackoffExponential, RetryOn: []string{"503"}},
+			core.RetryPolicy{},
+			retrier{3, time.Millisecond, BackoffExponential, []string{"503"}},
+			"",
+		},
+		{
+			"test case overrides run policy",
+			core.RetryPolicy{Retries: intPtr(3), RetryDelay: durationPtr(time.Millisecond), Backoff: BackoffExponential},
+			core.RetryPolicy{Retries: intPtr(0), Backoff: BackoffExponentialJitter, RetryOn: []string{"network"}},
+			retrier{0, time.Millisecond, BackoffExponentialJitter, []string{"network"}},
+			"",
+		},
+		{"negative retries", core.RetryPolicy{}, core.RetryPolicy{Retries: intPtr(-1)}, retrier{}, "retries must be >= 0"},
+		{"negative delay", core.RetryPolicy{}, core.RetryPolicy{RetryDelay: durationPtr(-time.Second)}, retrier{}, "retryDelay must be >= 0"},
+		{"invalid backoff", core.RetryPolicy{}, core.RetryPolicy{Backoff: "linear"}, retrier{}, "backoff must be constant, exponential or exponential-jitter"},
+		{"invalid outcome", core.RetryPolicy{}, core.RetryPolicy{RetryOn: []string{"timeout"}}, retrier{}, "retryOn does not support \"timeout\""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rt, err := newRetrier(tc.run, tc.tc)
+
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %v", tc.expectErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			if rt.retries != tc.expected.retries || rt.delay != tc.expected.delay || rt.backoff != tc.expected.backoff || strings.Join(rt.retryOn, ",") != strings.Join(tc.expected.retryOn, ",") {
+				t.Fatalf("Retrier does not match\nexpected: %+v\nreceived: %+v", tc.expected, *rt)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		name     string
+		retryOn  []string
+		res      core.Result
+		expected bool
+	}{
+		{"network error", []string{"network"}, core.Result{NetworkError: true}, true},
+		{"network error not retried", []string{"503"}, core.Result{NetworkError: true}, false},
+		{"invalid test case", []string{"network"}, core.Result{}, false},
+		{"status code", []string{"502", "503"}, core.Result{StatusCode: 503}, true},
+		{"status code class", []string{"5xx"}, core.Result{StatusCode: 504}, true},
+		{"status code class in upper case", []string{"5XX"}, core.Result{StatusCode: 500}, true},
+		{"other status code", []string{"network", "5xx"}, core.Result{StatusCode: 404}, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := &retrier{retryOn: tc.retryOn}
+
+			if received := rt.retryable(tc.res); received != tc.expected {
+				t.Fatalf("Retryable does not match\nexpected: %t\nreceived: %t", tc.expected, received)
+			}
+		})
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	defer func(original func() float64) { jitter = original }(jitter)
+
+	jitter = func() float64 { return 0.5 }
+
+	tt := []struct {
+		backoff  string
+		expected []time.Duration
+	}{
+		{BackoffConstant, []time.Duration{time.Second, time.Second, time.Second}},
+		{BackoffExponential, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+		{BackoffExponentialJitter, []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second}},
+	}
+
+	for _, tc := range tt {
+		rt := &retrier{delay: time.Second, backoff: tc.backoff}
+
+		for i, expected := range tc.expected {
+			if received := rt.wait(i + 1); received != expected {
+				t.Fatalf("%s delay before retry %d does not match\nexpected: %s\nreceived: %s", tc.backoff, i+1, expected, received)
+			}
+		}
+	}
+}
+
+func TestNewJitter(t *testing.T) {
+	t.Parallel()
+
+	sequence := func(jitter func() float64) []float64 {
+		return []float64{jitter(), jitter(), jitter()}
+	}
+
+	first, again, other := sequence(newJitter(1)), sequence(newJitter(1)), sequence(newJitter(2))
+
+	if !reflect.DeepEqual(first, again) {
+		t.Fatalf("Expected the same seed to give the same jitter\nexpected: %v\nreceived: %v", first, again)
+	}
+
+	if reflect.DeepEqual(first, other) {
+		t.Fatalf("Expected another seed to give another jitter\nreceived: %v", other)
+	}
+
+	for _, j := range first {
+		if j < 0 || j >= 1 {
+			t.Fatalf("Jitter is out of range\nexpected: [0.0,1.0)\nreceived: %v", j)
+		}
+	}
+}
+
+// flakyRequester fails with the given status codes before passing, status
+// code 0 is a network error. Invalid test cases always fail.
+type flakyRequester struct {
+	mu       sync.Mutex
+	failures map[string][]int
+	invalid  map[string]bool
+}
+
+func (r *flakyRequester) Request(ctx context.Context, tc core.TestCase) (core.Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.invalid[tc.Name] {
+		return core.Result{}, errors.New("does not have url field")
+	}
+
+	if codes := r.failures[tc.Name]; len(codes) > 0 {
+		r.failures[tc.Name] = codes[1:]
+		return core.Result{StatusCode: codes[0], NetworkError: codes[0] == 0}, errors.New("unexpected status code")
+	}
+
+	return core.Result{Passed: true, StatusCode: 200}, nil
+}
+
+func TestRunnerWithRetries(t *testing.T) {
+	var buffer bytes.Buffer
+
+	runner := NewRunner(2, time.Second, false, &buffer, &buffer)
+	runner.SetRetryPolicy(core.RetryPolicy{Retries: intPtr(2), RetryDelay: durationPtr(time.Millisecond)})
+
+	requester := &flakyRequester{failures: map[string][]int{
+		"deploying": {503, 0},
+		"missing":   {404},
+		"down":      {503, 503, 503, 503},
+		"no retry":  {503},
+	}, invalid: map[string]bool{"invalid": true}}
+
+	_, err := runner.Run(requester, &core.Testsuite{Tests: []core.TestCase{
+		{Name: "deploying"},
+		{Name: "missing"},
+		{Name: "down", RetryPolicy: core.RetryPolicy{Retries: intPtr(3), Backoff: BackoffExponential}},
+		{Name: "no retry", RetryPolicy: core.RetryPolicy{Retries: intPtr(0)}},
+		{Name: "invalid"},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	expected := map[string]struct {
+		passed   bool
+		attempts int
+	}{
+		"deploying": {true, 3},
+		"missing":   {false, 1},
+		"down":      {false, 4},
+		"no retry":  {false, 1},
+		"invalid":   {false, 1},
+	}
+
+	for _, rp := range runner.reports {
+		if e := expected[rp.Name]; rp.Passed() != e.passed || rp.Attempts != e.attempts {
+			t.Fatalf("Testcase %q does not match\nexpected: passed %t after %d attempts\nreceived: passed %t after %d attempts", rp.Name, e.passed, e.attempts, rp.Passed(), rp.Attempts)
+		}
+	}
+
+	if !strings.Contains(buffer.String(), "PASS: testcase #1 \"deploying\" after 3 attempts") {
+		t.Fatalf("Output does not show the attempts\nreceived: %s", buffer.String())
+	}
+}
+
+func TestRunnerWithInvalidRetryPolicy(t *testing.T) {
+	runner := newTestRunner(1, 1, false)
+
+	_, err := runner.Run(&testRequester{}, &core.Testsuite{Tests: []core.TestCase{{Name: "a", RetryPolicy: core.RetryPolicy{Backoff: "linear"}}}})
+	if err == nil || !strings.Contains(err.Error(), "testcase \"a\": backoff must be") {
+		t.Fatalf("Expected error does not match\nexpected: testcase \"a\": backoff must be\nreceived: %v", err)
+	}
+}
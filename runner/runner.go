@@ -5,17 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"sync"
 	"time"
 
 	"github.com/amad/smoker/core"
-	"github.com/amad/smoker/runner/internal/report"
+	"github.com/amad/smoker/reporter"
+	"github.com/amad/smoker/vars"
 )
 
 // NewRunner creates and returns a new Runner.
 func NewRunner(workers int, timeout time.Duration, stopOnFailure bool, stdout io.StringWriter, stderr io.StringWriter) *Runner {
-	reports := []core.TestResult{}
+	reports := []*core.TestReport{}
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 
@@ -23,23 +23,67 @@ func NewRunner(workers int, timeout time.Duration, stopOnFailure bool, stdout io
 		workers:       workers,
 		timeout:       timeout,
 		stopOnFailure: stopOnFailure,
-		stdout:        stdout,
-		stderr:        stderr,
 		reports:       reports,
+		reporters:     []core.Reporter{reporter.NewText(stdout, stderr)},
 		ctx:           ctx,
 		cancelFunc:    cancelFunc,
+		filter:        &filter{},
 	}
 }
 
 // Runner is a type to manage and run tests and provide logs.
 type Runner struct {
-	workers        int
-	timeout        time.Duration
-	stopOnFailure  bool
-	stdout, stderr io.StringWriter
-	reports        []core.TestResult
-	ctx            context.Context
-	cancelFunc     context.CancelFunc
+	workers       int
+	timeout       time.Duration
+	stopOnFailure bool
+	retryPolicy   core.RetryPolicy
+	filter        *filter
+	reports       []*core.TestReport
+	reporters     []core.Reporter
+	reporter      core.Reporter
+	reporterMu    sync.Mutex
+	reporterErr   error
+	ctx           context.Context
+	cancelFunc    context.CancelFunc
+}
+
+// SetRetryPolicy sets how failed test cases are retried. Test cases can
+// override each field of the policy. By default failed test cases are not
+// retried.
+func (r *Runner) SetRetryPolicy(p core.RetryPolicy) {
+	r.retryPolicy = p
+}
+
+// SetFilter sets which test cases are run. Test cases that a selected test
+// case depends on are run as well.
+func (r *Runner) SetFilter(f Filter) error {
+	compiled, err := f.compile()
+	if err != nil {
+		return err
+	}
+
+	r.filter = compiled
+
+	return nil
+}
+
+// AddReporter adds a reporter that receives the events of the run, after
+// the text output written to stdout and stderr.
+func (r *Runner) AddReporter(rp core.Reporter) {
+	r.reporters = append(r.reporters, rp)
+}
+
+// AddReport adds a reporter writing a report in the given format to w.
+// See the reporter package for the supported formats.
+func (r *Runner) AddReport(format string, w io.Writer) error {
+	rp, err := reporter.New(format, w)
+	if err != nil {
+		return err
+	}
+
+	r.AddReporter(rp)
+
+	return nil
 }
 
 // Run smoke test on a testsuite and provides results.
@@ -48,41 +92,77 @@ func (r *Runner) Run(requester core.Requester, testsuite *core.Testsuite) (bool,
 		return false, errors.New("no testcase found in this testsuite")
 	}
 
-	r.printfOut("Tests:   %d total", len(testsuite.Tests))
-	r.printfOut("Workers: %d total", r.workers)
-	r.printfOut("Timeout: %s", r.timeout.String())
-	r.printfOut("Stop on failure: %t\n", r.stopOnFailure)
+	jobs, err := plan(testsuite.Tests)
+	if err != nil {
+		return false, err
+	}
+
+	jobs, err = selectJobs(jobs, r.filter)
+	if err != nil {
+		return false, err
+	}
+
+	if len(jobs) < 1 {
+		return false, errors.New("no testcase matches the filters")
+	}
+
+	selected := make([]core.TestCase, len(jobs))
+	for i, j := range jobs {
+		selected[i] = j.tc
+	}
+
+	for _, j := range jobs {
+		if j.retry, err = newRetrier(r.retryPolicy, j.tc.RetryPolicy); err != nil {
+			return false, fmt.Errorf("testcase %q: %w", j.tc.Name, err)
+		}
+	}
 
 	start := time.Now()
 
-	var wg sync.WaitGroup
-	reportsChan := make(chan core.TestResult, len(testsuite.Tests))
-	poolChan := make(chan struct{}, r.getPoolsize(testsuite))
+	r.reporter = reporter.Multi(r.reporters...)
+	r.emit(func(rp core.Reporter) error {
+		return rp.SuiteStarted(core.SuiteStart{
+			Tests:         len(jobs),
+			Filtered:      len(testsuite.Tests) - len(jobs),
+			Workers:       r.workers,
+			Timeout:       r.timeout,
+			TestCases:     selected,
+			StopOnFailure: r.stopOnFailure,
+			StartedAt:     start,
+		})
+	})
 
-	r.printfOut("Waiting for results\n")
-	go r.reportWriter(&wg, reportsChan)
+	var wg sync.WaitGroup
+	poolChan := make(chan struct{}, r.getPoolsize(len(jobs)))
 
-	for i, tc := range testsuite.Tests {
+	for _, j := range jobs {
 		poolChan <- struct{}{}
 
 		if r.isClosing() {
 			break
 		}
 
-		wg.Add(2) // delta=2 to sync worker and reportWriter.
-		go r.worker(&wg, requester, i+1, tc, poolChan, reportsChan)
+		wg.Add(1)
+		go r.worker(&wg, requester, j, poolChan)
 	}
 
 	wg.Wait()
 
-	close(reportsChan)
 	close(poolChan)
 	defer r.cancelFunc()
 
-	r.printfOut("\nElapsed: %.2fs", time.Since(start).Seconds())
+	elapsed := time.Since(start)
+
+	r.emit(func(rp core.Reporter) error {
+		return rp.SuiteFinished(core.SuiteSummary{StartedAt: start, Elapsed: elapsed, Reports: r.reports})
+	})
+
+	if r.reporterErr != nil {
+		return false, r.reporterErr
+	}
 
 	for _, rp := range r.reports {
-		if !rp.Passed() {
+		if !rp.Passed() && !rp.Skipped() {
 			return false, nil
 		}
 	}
@@ -90,28 +170,151 @@ func (r *Runner) Run(requester core.Requester, testsuite *core.Testsuite) (bool,
 	return true, nil
 }
 
-func (r *Runner) worker(wg *sync.WaitGroup, requester core.Requester, idx int, tc core.TestCase, pool <-chan struct{}, reportsChan chan<- core.TestResult) {
+// worker waits for the dependencies of a job, runs it and reports the result.
+// Jobs are started in dependency order, so every dependency of a job was
+// started before it and waiting here can not block the pool. The result is
+// reported before the slot of the pool is released, so with one worker the
+// events of each test case reach the reporters before the next one starts.
+func (r *Runner) worker(wg *sync.WaitGroup, requester core.Requester, j *job, pool <-chan struct{}) {
 	defer wg.Done()
+	defer close(j.done)
+
+	tc, err := r.prepare(j)
+
+	var skip *skipError
+	if errors.As(err, &skip) {
+		j.skipped = true
+		r.finish(&core.TestReport{
+			Index:      j.index,
+			Name:       tc.Name,
+			Status:     core.StatusSkipped,
+			Source:     tc.Source,
+			URL:        tc.URL,
+			Method:     tc.Method,
+			SkipReason: skip.reason,
+		})
+
+		<-pool
+
+		return
+	}
+
+	var res core.Result
 
-	s := time.Now()
-	res, err := requester.Request(tc)
+	startedAt := time.Now()
 
-	reportsChan <- &report.TestReport{
-		Index:    idx,
-		Name:     tc.Name,
-		Status:   res,
-		Err:      err,
-		Duration: time.Since(s),
+	r.emit(func(rp core.Reporter) error {
+		return rp.TestStarted(core.TestStart{Index: j.index, TestCase: tc, StartedAt: startedAt})
+	})
+
+	attempts := 0
+	if err == nil {
+		res, attempts, err = r.send(requester, j.retry, tc)
+	}
+	finishedAt := time.Now()
+
+	j.passed = res.Passed
+	if res.Passed {
+		j.captured = res.Captured
 	}
 
-	if !res {
+	status := core.StatusFailed
+
+	switch {
+	case res.Passed:
+		status = core.StatusPassed
+	case r.isClosing() && errors.Is(err, context.Canceled):
+		status = core.StatusCancelled
+	}
+
+	r.finish(&core.TestReport{
+		Index:      j.index,
+		Name:       tc.Name,
+		Status:     status,
+		Err:        err,
+		Duration:   finishedAt.Sub(startedAt),
+		Source:     tc.Source,
+		URL:        tc.URL,
+		Method:     tc.Method,
+		StatusCode: res.StatusCode,
+		RemoteAddr: res.RemoteAddr,
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		Attempts:   attempts,
+	})
+
+	if status == core.StatusFailed {
 		r.shouldStopOnFailure()
 	}
 
 	<-pool
 }
 
-// Stop pauses off the runner.
+// send sends the request of a test case and retries failed attempts as long
+// as the retry policy allows. It returns the outcome of the last attempt and
+// the number of attempts.
+func (r *Runner) send(requester core.Requester, rt *retrier, tc core.TestCase) (core.Result, int, error) {
+	for attempt := 1; ; attempt++ {
+		res, err := requester.Request(r.ctx, tc)
+		if res.Passed || attempt > rt.retries || !rt.retryable(res) {
+			return res, attempt, err
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return res, attempt, err
+		case <-time.After(rt.wait(attempt)):
+		}
+	}
+}
+
+// prepare waits for the dependencies of a job and returns its test case with
+// the values captured by the test cases it depends on. Test cases are
+// templates, escaped references are turned into their literal text.
+func (r *Runner) prepare(j *job) (core.TestCase, error) {
+	tc := j.tc
+
+	if tc.Skip != "" {
+		return tc, &skipError{reason: tc.Skip}
+	}
+
+	for _, dep := range j.deps {
+		select {
+		case <-dep.done:
+		case <-r.ctx.Done():
+			return tc, r.ctx.Err()
+		}
+
+		if dep.skipped {
+			return tc, &skipError{reason: fmt.Sprintf("depends on testcase #%d \"%s\" which was skipped", dep.index, dep.tc.Name)}
+		}
+
+		if !dep.passed {
+			return tc, fmt.Errorf("depends on testcase #%d \"%s\" which did not pass", dep.index, dep.tc.Name)
+		}
+	}
+
+	if err := vars.ExpandTestCase(&tc, j.lookup); err != nil {
+		return tc, err
+	}
+
+	tc.URL = core.ResolveURL(tc.BaseURL, tc.URL)
+	tc.BaseURL = ""
+
+	return tc, nil
+}
+
+// skipError is returned by prepare when a test case is not run.
+type skipError struct {
+	reason string
+}
+
+func (e *skipError) Error() string {
+	return "skipped: " + e.reason
+}
+
+// Stop pauses off the runner and cancels the requests in flight, their test
+// cases are reported as cancelled.
 // used for signal handling or when stop on failure is enabled.
 func (r *Runner) Stop() {
 	r.cancelFunc()
@@ -131,38 +334,30 @@ func (r *Runner) shouldStopOnFailure() {
 	}
 }
 
-func (r *Runner) reportWriter(wg *sync.WaitGroup, reportsChan <-chan core.TestResult) {
-	for report := range reportsChan {
+// finish records the report of a test case and sends it to the reporters.
+func (r *Runner) finish(report *core.TestReport) {
+	r.emit(func(rp core.Reporter) error {
 		r.reports = append(r.reports, report)
 
-		if report.Passed() {
-			r.printfOut(report.String())
-		} else {
-			r.printfErrOut(report.String())
-		}
-
-		wg.Done()
-	}
+		return rp.TestFinished(report)
+	})
 }
 
-func (r *Runner) getPoolsize(ts *core.Testsuite) int {
-	if r.workers >= len(ts.Tests) {
-		return len(ts.Tests)
-	}
-
-	return r.workers
-}
+// emit sends an event to the reporters, one event at a time.
+// The first error is kept and returned by Run.
+func (r *Runner) emit(event func(rp core.Reporter) error) {
+	r.reporterMu.Lock()
+	defer r.reporterMu.Unlock()
 
-func (r *Runner) printfOut(msg string, params ...interface{}) {
-	_, err := r.stdout.WriteString(fmt.Sprintf(msg, params...) + "\n")
-	if err != nil {
-		log.Fatal(err)
+	if err := event(r.reporter); err != nil && r.reporterErr == nil {
+		r.reporterErr = err
 	}
 }
 
-func (r *Runner) printfErrOut(msg string, params ...interface{}) {
-	_, err := r.stderr.WriteString(fmt.Sprintf(msg, params...) + "\n")
-	if err != nil {
-		log.Fatal(err)
+func (r *Runner) getPoolsize(tests int) int {
+	if r.workers >= tests {
+		return tests
 	}
+
+	return r.workers
 }
@@ -2,11 +2,15 @@ package runner
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/reporter"
 )
 
 func TestNewRunner(t *testing.T) {
@@ -24,26 +28,6 @@ func newTestRunner(workers int, timeout int, stopOnFailure bool) *Runner {
 	return NewRunner(workers, time.Duration(timeout)*time.Second, stopOnFailure, &buffer, &buffer)
 }
 
-func TestPrintfOutAndPrintfErrOut(t *testing.T) {
-	expectedOutput := "test msg\n"
-	expectedErrOutput := "test error\n"
-
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	r := NewRunner(1, time.Second, false, &stdout, &stderr)
-
-	r.printfErrOut("test %s", "error")
-	r.printfOut("test %s", "msg")
-
-	if stdout.String() != expectedOutput {
-		t.Fatalf("stdout output does not match\nexpected: %s\nreceived: %s", expectedOutput, stdout.String())
-	}
-
-	if stderr.String() != expectedErrOutput {
-		t.Fatalf("stderr output does not match\nexpected: %s\nreceived: %s", expectedErrOutput, stderr.String())
-	}
-}
-
 func TestGetPoolsize(t *testing.T) {
 	tt := []struct {
 		name           string
@@ -60,12 +44,7 @@ func TestGetPoolsize(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			var buffer bytes.Buffer
 			r := NewRunner(tc.numWorkers, time.Second, false, &buffer, &buffer)
-			ts := &core.Testsuite{}
-			for n := 0; n < tc.numTestcases; n++ {
-				ts.Tests = append(ts.Tests, core.TestCase{})
-			}
-
-			poolSize := r.getPoolsize(ts)
+			poolSize := r.getPoolsize(tc.numTestcases)
 
 			if poolSize != tc.expectPoolSize {
 				t.Fatalf("getPoolsize error\n expected: %d\nreceived: %d", tc.expectPoolSize, poolSize)
@@ -76,12 +55,12 @@ func TestGetPoolsize(t *testing.T) {
 
 type testRequester struct{}
 
-func (r *testRequester) Request(tc core.TestCase) (bool, error) {
+func (r *testRequester) Request(ctx context.Context, tc core.TestCase) (core.Result, error) {
 	if tc.Name == "fail" {
-		return false, errors.New("testRequester fake failure")
+		return core.Result{}, errors.New("testRequester fake failure")
 	}
 
-	return true, nil
+	return core.Result{Passed: true}, nil
 }
 
 func TestRunnerWithEmptySuite(t *testing.T) {
@@ -134,8 +113,10 @@ func TestRunner(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+
 			requester := &testRequester{}
-			runner := newTestRunner(1, 1, tc.stopOnFailure)
+			runner := NewRunner(1, time.Second, tc.stopOnFailure, &buffer, &buffer)
 
 			ok, _ := runner.Run(requester, tc.testsuite)
 
@@ -143,7 +124,7 @@ func TestRunner(t *testing.T) {
 				t.Fatalf("Expected report to have failed test cases")
 			}
 
-			if buffer := runner.stdout.(*bytes.Buffer); buffer.String() == "" {
+			if buffer.String() == "" {
 				t.Fatalf("Runner is not writing output")
 			}
 
@@ -152,7 +133,7 @@ func TestRunner(t *testing.T) {
 	}
 }
 
-func expectReport(t *testing.T, reports *[]core.TestResult, expectPassedCount int, expectFailedCount int) {
+func expectReport(t *testing.T, reports *[]*core.TestReport, expectPassedCount int, expectFailedCount int) {
 	var cp = 0
 	var cf = 0
 	for _, report := range *reports {
@@ -171,3 +152,297 @@ func expectReport(t *testing.T, reports *[]core.TestResult, expectPassedCount in
 		t.Fatalf("Expected report to have %d failed tests, got %d", expectFailedCount, cf)
 	}
 }
+
+type capturingRequester struct{}
+
+func (r *capturingRequester) Request(ctx context.Context, tc core.TestCase) (core.Result, error) {
+	switch tc.Name {
+	case "login":
+		time.Sleep(10 * time.Millisecond)
+		return core.Result{Passed: true, Captured: map[string]string{"token": "abc"}}, nil
+	case "login admin":
+		return core.Result{Passed: true, Captured: map[string]string{"token": "admin"}}, nil
+	case "login user":
+		time.Sleep(10 * time.Millisecond)
+		return core.Result{Passed: true, Captured: map[string]string{"token": "user"}}, nil
+	case "list orders":
+		return core.Result{Passed: true, Captured: map[string]string{"next": "/orders?page=2", "other": "https://other.example.com/orders"}}, nil
+	case "fail":
+		return core.Result{}, errors.New("testRequester fake failure")
+	}
+
+	if expected, ok := tc.Headers["X-Expected-URL"]; ok {
+		if tc.URL != expected || tc.BaseURL != "" {
+			return core.Result{}, fmt.Errorf("unexpected url %q (base %q)", tc.URL, tc.BaseURL)
+		}
+
+		return core.Result{Passed: true}, nil
+	}
+
+	if expected, ok := tc.Headers["X-Expected-Body"]; ok {
+		if tc.Body != expected {
+			return core.Result{}, fmt.Errorf("unexpected body %q", tc.Body)
+		}
+
+		return core.Result{Passed: true}, nil
+	}
+
+	if expected, ok := tc.Headers["X-Expected-Authorization"]; ok {
+		if tc.Headers["Authorization"] != expected {
+			return core.Result{}, fmt.Errorf("unexpected authorization header %q", tc.Headers["Authorization"])
+		}
+
+		return core.Result{Passed: true}, nil
+	}
+
+	if tc.Headers["Authorization"] != "Bearer abc" {
+		return core.Result{}, fmt.Errorf("unexpected authorization header %q", tc.Headers["Authorization"])
+	}
+
+	return core.Result{Passed: true}, nil
+}
+
+func TestRunnerWithDependencies(t *testing.T) {
+	tt := []struct {
+		name              string
+		testsuite         *core.Testsuite
+		expectPassedCount int
+		expectFailedCount int
+		expectOutput      string
+	}{
+		{
+			"captured values are used by later test cases",
+			&core.Testsuite{Tests: []core.TestCase{
+				{Name: "profile", Headers: map[string]string{"Authorization": "Bearer ${token}"}},
+				{Name: "login", Extract: map[string]core.Extractor{"token": {JSON: "$.token"}}},
+				{Name: "orders", Headers: map[string]string{"Authorization": "Bearer ${token}"}, DependsOn: []string{"login"}},
+			}},
+			3,
+			0,
+			"",
+		},
+		{
+			"captured values come from the closest producer",
+			&core.Testsuite{Tests: []core.TestCase{
+				{Name: "admin call", DependsOn: []string{"login user"}, Headers: map[string]string{"Authorization": "Bearer ${token}", "X-Expected-Authorization": "Bearer admin"}},
+				{Name: "login admin", Extract: map[string]core.Extractor{"token": {JSON: "$.token"}}},
+				{Name: "login user", Extract: map[string]core.Extractor{"token": {JSON: "$.token"}}},
+				{Name: "user call", Headers: map[string]string{"Authorization": "Bearer ${token}", "X-Expected-Authorization": "Bearer user"}},
+			}},
+			4,
+			0,
+			"",
+		},
+		{
+			"escaped references are kept as literal text",
+			&core.Testsuite{Tests: []core.TestCase{
+				{Name: "login", Extract: map[string]core.Extractor{"token": {JSON: "$.token"}}},
+				{Name: "escaped", Body: "$${token} ${token}", DependsOn: []string{"login"}, Headers: map[string]string{"X-Expected-Body": "$${token} abc"}},
+			}},
+			2,
+			0,
+			"",
+		},
+		{
+			"base url is resolved once captured values are expanded",
+			&core.Testsuite{Tests: []core.TestCase{
+				{Name: "list orders", Extract: map[string]core.Extractor{"next": {Header: "Link"}, "other": {Header: "Location"}}},
+				{Name: "next page", URL: "${next}", BaseURL: "https://api.example.com/v1", Headers: map[string]string{"X-Expected-URL": "https://api.example.com/v1/orders?page=2"}},
+				{Name: "other host", URL: "${other}", BaseURL: "https://api.example.com/v1", Headers: map[string]string{"X-Expected-URL": "https://other.example.com/orders"}},
+			}},
+			3,
+			0,
+			"",
+		},
+		{
+			"test cases fail when a dependency did not pass",
+			&core.Testsuite{Tests: []core.TestCase{
+				{Name: "fail"},
+				{Name: "after", DependsOn: []string{"fail"}},
+			}},
+			0,
+			2,
+			"FAIL: testcase #2 \"after\" depends on testcase #1 \"fail\" which did not pass",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+
+			runner := NewRunner(4, time.Second, false, &buffer, &buffer)
+
+			_, err := runner.Run(&capturingRequester{}, tc.testsuite)
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			expectReport(t, &runner.reports, tc.expectPassedCount, tc.expectFailedCount)
+
+			if !strings.Contains(buffer.String(), tc.expectOutput) {
+				t.Fatalf("Output does not contain: %s\nreceived: %s", tc.expectOutput, buffer.String())
+			}
+		})
+	}
+}
+
+func TestRunnerWithDependencyCycle(t *testing.T) {
+	runner := newTestRunner(1, 1, false)
+
+	_, err := runner.Run(&testRequester{}, &core.Testsuite{Tests: []core.TestCase{{Name: "a", DependsOn: []string{"a"}}}})
+	if err == nil || err.Error() != "dependency cycle between testcases \"a\"" {
+		t.Fatalf("Expected error does not match\nexpected: dependency cycle between testcases \"a\"\nreceived: %v", err)
+	}
+}
+
+func TestRunnerWithReports(t *testing.T) {
+	var junit, jsonDoc, jsonLines bytes.Buffer
+
+	runner := newTestRunner(2, 1, false)
+
+	if err := runner.AddReport("html", &junit); err == nil {
+		t.Fatal("Expected to throw error for unsupported report format")
+	}
+
+	for format, w := range map[string]*bytes.Buffer{reporter.FormatJUnit: &junit, reporter.FormatJSON: &jsonDoc, reporter.FormatJSONLines: &jsonLines} {
+		if err := runner.AddReport(format, w); err != nil {
+			t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+		}
+	}
+
+	_, err := runner.Run(&testRequester{}, &core.Testsuite{Tests: []core.TestCase{{Name: "a"}, {Name: "fail", Source: "suite.json"}}})
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	for _, expected := range []string{`<testsuite name="smoker" tests="2" failures="1"`, `<testcase name="a" classname="smoker"`, `<testcase name="fail" classname="suite.json" file="suite.json"`} {
+		if !strings.Contains(junit.String(), expected) {
+			t.Fatalf("JUnit report does not contain: %s\nreceived: %s", expected, junit.String())
+		}
+	}
+
+	if !strings.Contains(jsonDoc.String(), `"total": 2,
+  "passed": 1,
+  "failed": 1,`) {
+		t.Fatalf("JSON report does not contain totals\nreceived: %s", jsonDoc.String())
+	}
+
+	if lines := strings.Count(jsonLines.String(), "\n"); lines != 2 {
+		t.Fatalf("Expected JSON lines report to have 2 lines, got %d\nreceived: %s", lines, jsonLines.String())
+	}
+}
+
+type recordingReporter struct {
+	events []string
+	err    error
+}
+
+func (r *recordingReporter) SuiteStarted(s core.SuiteStart) error {
+	r.events = append(r.events, fmt.Sprintf("suite started %d", s.Tests))
+	return nil
+}
+
+func (r *recordingReporter) TestStarted(t core.TestStart) error {
+	r.events = append(r.events, fmt.Sprintf("test started #%d %s", t.Index, t.TestCase.Name))
+	return nil
+}
+
+func (r *recordingReporter) TestFinished(tr *core.TestReport) error {
+	r.events = append(r.events, fmt.Sprintf("test finished #%d %s %t", tr.Index, tr.Name, tr.Passed()))
+	return r.err
+}
+
+func (r *recordingReporter) SuiteFinished(s core.SuiteSummary) error {
+	r.events = append(r.events, fmt.Sprintf("suite finished %d", len(s.Reports)))
+	return nil
+}
+
+func TestRunnerWithReporters(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	first := &recordingReporter{}
+	second := &recordingReporter{err: errors.New("disk full")}
+
+	runner := NewRunner(1, time.Second, false, &stdout, &stderr)
+	runner.AddReporter(first)
+	runner.AddReporter(second)
+
+	_, err := runner.Run(&testRequester{}, &core.Testsuite{Tests: []core.TestCase{{Name: "a"}, {Name: "fail"}}})
+	if err == nil || err.Error() != "disk full" {
+		t.Fatalf("Expected error does not match\nexpected: disk full\nreceived: %v", err)
+	}
+
+	expected := []string{
+		"suite started 2",
+		"test started #1 a",
+		"test finished #1 a true",
+		"test started #2 fail",
+		"test finished #2 fail false",
+		"suite finished 2",
+	}
+
+	for _, rp := range []*recordingReporter{first, second} {
+		if strings.Join(rp.events, "\n") != strings.Join(expected, "\n") {
+			t.Fatalf("Reporter events do not match\nexpected: %s\nreceived: %s", expected, rp.events)
+		}
+	}
+
+	if !strings.Contains(stdout.String(), "PASS: testcase #1 \"a\"") || !strings.Contains(stderr.String(), "FAIL: testcase #2 \"fail\"") {
+		t.Fatalf("Default reporter is not writing output\nstdout: %s\nstderr: %s", stdout.String(), stderr.String())
+	}
+}
+
+// blockingRequester fails the test case named fail and blocks the others until they are cancelled.
+type blockingRequester struct{}
+
+func (r *blockingRequester) Request(ctx context.Context, tc core.TestCase) (core.Result, error) {
+	if tc.Name == "fail" {
+		time.Sleep(20 * time.Millisecond)
+		return core.Result{}, errors.New("testRequester fake failure")
+	}
+
+	<-ctx.Done()
+
+	return core.Result{}, fmt.Errorf("request cancelled: %w", ctx.Err())
+}
+
+func TestRunnerCancelsRequestsOnStop(t *testing.T) {
+	var buffer bytes.Buffer
+
+	runner := NewRunner(3, time.Second, true, &buffer, &buffer)
+
+	start := time.Now()
+
+	ok, err := runner.Run(&blockingRequester{}, &core.Testsuite{Tests: []core.TestCase{
+		{Name: "slow"},
+		{Name: "fail"},
+		{Name: "after slow", DependsOn: []string{"slow"}},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	if ok {
+		t.Fatal("Expected run to fail")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected requests to be cancelled, run took %s", elapsed)
+	}
+
+	expected := map[string]core.Status{"slow": core.StatusCancelled, "fail": core.StatusFailed, "after slow": core.StatusCancelled}
+
+	for _, rp := range runner.reports {
+		if rp.Status != expected[rp.Name] {
+			t.Fatalf("Status of testcase %q does not match\nexpected: %s\nreceived: %s", rp.Name, expected[rp.Name], rp.Status)
+		}
+	}
+
+	if len(runner.reports) != len(expected) {
+		t.Fatalf("Expected %d reports, got %d", len(expected), len(runner.reports))
+	}
+
+	if !strings.Contains(buffer.String(), "CANCEL: testcase #1 \"slow\"") {
+		t.Fatalf("Output does not contain the cancelled testcase\nreceived: %s", buffer.String())
+	}
+}
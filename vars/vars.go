@@ -0,0 +1,387 @@
+package vars
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/amad/smoker/core"
+)
+
+// Lookup returns the value of a variable and whether it is defined.
+type Lookup func(name string) (string, bool)
+
+// Chain returns a Lookup that tries each lookup in order and returns the first defined value.
+func Chain(lookups ...Lookup) Lookup {
+	return func(name string) (string, bool) {
+		for _, lookup := range lookups {
+			if value, ok := lookup(name); ok {
+				return value, true
+			}
+		}
+
+		return "", false
+	}
+}
+
+// Map returns a Lookup that reads variables from a map.
+func Map(m map[string]string) Lookup {
+	return func(name string) (string, bool) {
+		value, ok := m[name]
+		return value, ok
+	}
+}
+
+// UndefinedError is returned when a referenced variable is not defined.
+type UndefinedError struct {
+	Name string
+}
+
+func (e *UndefinedError) Error() string {
+	return fmt.Sprintf("undefined variable %q", e.Name)
+}
+
+// Expand replaces ${name} references in s with values returned by lookup.
+// $${name} is kept as the literal text ${name}.
+func Expand(s string, lookup Lookup) (string, error) {
+	return expand(s, lookup, nil)
+}
+
+// Template replaces ${name} references in s like Expand, but keeps the
+// references to the variables in keep, so the result is a template to be
+// expanded later. Text that would read as a reference, coming from an escaped
+// reference or from a value, is escaped as $${.
+func Template(s string, lookup Lookup, keep map[string]bool) (string, error) {
+	if keep == nil {
+		keep = map[string]bool{}
+	}
+
+	return expand(s, lookup, keep)
+}
+
+// expand expands s, returning a template when keep is not nil.
+func expand(s string, lookup Lookup, keep map[string]bool) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	// text is the literal text since the last kept reference, it is escaped
+	// as a whole so a value ending with $ can not start a reference.
+	var b, text strings.Builder
+
+	flush := func() {
+		if keep != nil {
+			b.WriteString(strings.Replace(text.String(), "${", "$${", -1))
+		} else {
+			b.WriteString(text.String())
+		}
+
+		text.Reset()
+	}
+
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			text.WriteString(s)
+			break
+		}
+
+		if i > 0 && s[i-1] == '$' {
+			text.WriteString(s[:i-1])
+			text.WriteString("${")
+			s = s[i+2:]
+
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference in %q", s[i:])
+		}
+
+		name := s[i+2 : i+end]
+		if !validName(name) {
+			return "", fmt.Errorf("invalid variable name %q", name)
+		}
+
+		text.WriteString(s[:i])
+		s = s[i+end+1:]
+
+		if keep[name] {
+			if strings.HasSuffix(text.String(), "$") {
+				return "", fmt.Errorf("variable %q can not follow a $", name)
+			}
+
+			flush()
+			b.WriteString("${" + name + "}")
+
+			continue
+		}
+
+		value, ok := lookup(name)
+		if !ok {
+			return "", &UndefinedError{Name: name}
+		}
+
+		text.WriteString(value)
+	}
+
+	flush()
+
+	return b.String(), nil
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		switch {
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '.' || c == '-'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// ExpandTestCase replaces variable references in the URL, headers, body, TLS,
+// auth and signing settings and assertion values of a test case.
+func ExpandTestCase(tc *core.TestCase, lookup Lookup) error {
+	return expandTestCase(tc, func(s string) (string, error) {
+		return Expand(s, lookup)
+	})
+}
+
+// TemplateTestCase replaces variable references in the fields of a test case
+// like ExpandTestCase, but keeps the references to the variables in keep.
+// See Template.
+func TemplateTestCase(tc *core.TestCase, lookup Lookup, keep map[string]bool) error {
+	return expandTestCase(tc, func(s string) (string, error) {
+		return Template(s, lookup, keep)
+	})
+}
+
+func expandTestCase(tc *core.TestCase, expandString func(string) (string, error)) error {
+	var err error
+
+	expand := func(field string, s *string) {
+		if err != nil {
+			return
+		}
+
+		var expanded string
+		if expanded, err = expandString(*s); err != nil {
+			err = fmt.Errorf("%w in %s", err, field)
+			return
+		}
+
+		*s = expanded
+	}
+
+	expand("url", &tc.URL)
+	expand("defaults.baseURL", &tc.BaseURL)
+	expand("body", &tc.Body)
+	expand("tls.ca", &tc.TLS.CA)
+	expand("tls.cert", &tc.TLS.Cert)
+	expand("tls.key", &tc.TLS.Key)
+	expand("tls.serverName", &tc.TLS.ServerName)
+	expand("proxy", &tc.Proxy)
+	tc.Resolve = expandList(tc.Resolve, "resolve", expand)
+	tc.ConnectTo = expandList(tc.ConnectTo, "connectTo", expand)
+	tc.Headers = expandMap(tc.Headers, "headers", expand)
+	tc.Auth = expandAuth(tc.Auth, expand)
+	tc.Sign = expandSigning(tc.Sign, expand)
+
+	tc.Assertions.Body = expandList(tc.Assertions.Body, "assertions.body", expand)
+	tc.Assertions.BodyNotContains = expandList(tc.Assertions.BodyNotContains, "assertions.bodyNotContains", expand)
+	tc.Assertions.HeadersAbsent = expandList(tc.Assertions.HeadersAbsent, "assertions.headersAbsent", expand)
+
+	if tc.Assertions.Headers != nil {
+		assertions := make(map[string]core.HeaderAssertion, len(tc.Assertions.Headers))
+
+		for name, a := range tc.Assertions.Headers {
+			field := "assertions.headers." + name
+			expand(field, &a.Value)
+			expand(field, &a.Equals)
+			expand(field, &a.Matches)
+			assertions[name] = a
+		}
+
+		tc.Assertions.Headers = assertions
+	}
+
+	expand("assertions.finalURL", &tc.Assertions.FinalURL)
+
+	if tc.Assertions.Redirects != nil {
+		tc.Assertions.Redirects = append([]core.RedirectAssertion{}, tc.Assertions.Redirects...)
+
+		for i := range tc.Assertions.Redirects {
+			expand("assertions.redirects", &tc.Assertions.Redirects[i].Location)
+		}
+	}
+
+	if tc.Assertions.Cookies != nil {
+		assertions := make(map[string]core.CookieAssertion, len(tc.Assertions.Cookies))
+
+		for name, a := range tc.Assertions.Cookies {
+			field := "assertions.cookies." + name
+			if a.Value != nil {
+				value := *a.Value
+				expand(field, &value)
+				a.Value = &value
+			}
+
+			expand(field, &a.Matches)
+			assertions[name] = a
+		}
+
+		tc.Assertions.Cookies = assertions
+	}
+
+	if tc.Assertions.JSON != nil {
+		assertions := make(map[string]core.JSONAssertion, len(tc.Assertions.JSON))
+
+		for path, a := range tc.Assertions.JSON {
+			expand("assertions.json."+path, &a.Matches)
+			a.Equals = expandJSONString(a.Equals, "assertions.json."+path, expand)
+			assertions[path] = a
+		}
+
+		tc.Assertions.JSON = assertions
+	}
+
+	return err
+}
+
+// expandAuth expands the values of an auth block into a copy, so test cases
+// sharing the auth of the testsuite defaults are not affected.
+func expandAuth(auth *core.Auth, expand func(string, *string)) *core.Auth {
+	if auth == nil {
+		return nil
+	}
+
+	expanded := &core.Auth{}
+
+	if auth.Basic != nil {
+		basic := *auth.Basic
+		expand("auth.basic.username", &basic.Username)
+		expand("auth.basic.password", &basic.Password)
+		expanded.Basic = &basic
+	}
+
+	if auth.Bearer != nil {
+		bearer := *auth.Bearer
+		expand("auth.bearer.token", &bearer.Token)
+		expand("auth.bearer.env", &bearer.Env)
+		expand("auth.bearer.file", &bearer.File)
+		expanded.Bearer = &bearer
+	}
+
+	if auth.OAuth2 != nil {
+		oauth2 := *auth.OAuth2
+		expand("auth.oauth2.tokenURL", &oauth2.TokenURL)
+		expand("auth.oauth2.clientID", &oauth2.ClientID)
+		expand("auth.oauth2.clientSecret", &oauth2.ClientSecret)
+		oauth2.Scopes = expandList(oauth2.Scopes, "auth.oauth2.scopes", expand)
+		expanded.OAuth2 = &oauth2
+	}
+
+	return expanded
+}
+
+// expandSigning expands the values of a signing block into a copy, so test
+// cases sharing the signing of the testsuite defaults are not affected.
+func expandSigning(signing *core.Signing, expand func(string, *string)) *core.Signing {
+	if signing == nil {
+		return nil
+	}
+
+	expanded := &core.Signing{}
+
+	if signing.SigV4 != nil {
+		sigV4 := *signing.SigV4
+		expand("sign.sigv4.region", &sigV4.Region)
+		expand("sign.sigv4.service", &sigV4.Service)
+		expanded.SigV4 = &sigV4
+	}
+
+	if signing.HMAC != nil {
+		hmac := *signing.HMAC
+		expand("sign.hmac.secret", &hmac.Secret)
+		expand("sign.hmac.template", &hmac.Template)
+		expand("sign.hmac.value", &hmac.Value)
+		hmac.Headers = expandMap(hmac.Headers, "sign.hmac.headers", expand)
+		expanded.HMAC = &hmac
+	}
+
+	return expanded
+}
+
+// expandJSONString expands an encoded JSON string. Other values are returned as they are.
+func expandJSONString(raw json.RawMessage, field string, expand func(string, *string)) json.RawMessage {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		return raw
+	}
+
+	expand(field, &s)
+
+	encoded, _ := json.Marshal(s)
+
+	return encoded
+}
+
+// References returns the names of all variables referenced by a test case.
+func References(tc core.TestCase) []string {
+	var names []string
+
+	seen := make(map[string]bool)
+
+	_ = ExpandTestCase(&tc, func(name string) (string, bool) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+
+		return "", true
+	})
+
+	return names
+}
+
+// expandList expands the values of a list into a copy, so test cases sharing
+// the same list are not affected.
+func expandList(list []string, field string, expand func(string, *string)) []string {
+	if list == nil {
+		return nil
+	}
+
+	expanded := append([]string{}, list...)
+
+	for i := range expanded {
+		expand(field, &expanded[i])
+	}
+
+	return expanded
+}
+
+// expandMap expands the values of a map into a copy, so test cases sharing the
+// same map are not affected.
+func expandMap(m map[string]string, field string, expand func(string, *string)) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	expanded := make(map[string]string, len(m))
+
+	for name, value := range m {
+		expand(field+"."+name, &value)
+		expanded[name] = value
+	}
+
+	return expanded
+}
@@ -0,0 +1,215 @@
+package vars_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amad/smoker/core"
+	"github.com/amad/smoker/vars"
+)
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	lookup := vars.Map(map[string]string{"host": "example.com", "port": "8080", "api.token": "secret"})
+
+	tt := []struct {
+		name      string
+		input     string
+		expectRes string
+		expectErr string
+	}{
+		{"no reference", "https://example.com", "https://example.com", ""},
+		{"single reference", "https://${host}/", "https://example.com/", ""},
+		{"multiple references", "https://${host}:${port}/?t=${api.token}", "https://example.com:8080/?t=secret", ""},
+		{"escaped reference", "$${host} is ${host}", "${host} is example.com", ""},
+		{"dollar without brace", "price $5", "price $5", ""},
+		{"undefined variable", "https://${missing}/", "", "undefined variable \"missing\""},
+		{"unterminated reference", "https://${host", "", "unterminated variable reference"},
+		{"invalid name", "${1abc}", "", "invalid variable name \"1abc\""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := vars.Expand(tc.input, lookup)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if res != tc.expectRes {
+				t.Fatalf("Result does not match\nexpected: %s\nreceived: %s", tc.expectRes, res)
+			}
+		})
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+
+	lookup := vars.Map(map[string]string{"host": "example.com", "raw": "${host}", "cost": "5$"})
+	keep := map[string]bool{"token": true}
+
+	tt := []struct {
+		name      string
+		input     string
+		expectRes string
+		expectErr string
+	}{
+		{"keeps references", "https://${host}/?t=${token}", "https://example.com/?t=${token}", ""},
+		{"escapes escaped references", "$${token} is ${token}", "$${token} is ${token}", ""},
+		{"escapes values", "${raw}/${token}", "$${host}/${token}", ""},
+		{"escapes values ending with a dollar", "${cost}{host}", "5$${host}", ""},
+		{"errors when a dollar precedes a kept reference", "${cost}${token}", "", "variable \"token\" can not follow a $"},
+		{"undefined variable", "${missing}", "", "undefined variable \"missing\""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := vars.Template(tc.input, lookup, keep)
+
+			if err != nil {
+				if tc.expectErr == "" {
+					t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+				}
+
+				if ok := strings.Contains(err.Error(), tc.expectErr); !ok {
+					t.Fatalf("Expected error does not match\nexpected: %s\nreceived: %s", tc.expectErr, err.Error())
+				}
+
+				return
+			}
+
+			if tc.expectErr != "" {
+				t.Fatalf("Expected to throw error\nexpected: %s\nreceived: <nil>", tc.expectErr)
+			}
+
+			if res != tc.expectRes {
+				t.Fatalf("Result does not match\nexpected: %s\nreceived: %s", tc.expectRes, res)
+			}
+
+			expanded, err := vars.Expand(res, vars.Map(map[string]string{"token": "secret"}))
+			if err != nil {
+				t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+			}
+
+			if direct, _ := vars.Expand(tc.input, vars.Chain(lookup, vars.Map(map[string]string{"token": "secret"}))); expanded != direct {
+				t.Fatalf("Expanded template does not match\nexpected: %s\nreceived: %s", direct, expanded)
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	lookup := vars.Chain(vars.Map(map[string]string{"a": "first"}), vars.Map(map[string]string{"a": "second", "b": "second"}))
+
+	if v, _ := lookup("a"); v != "first" {
+		t.Fatalf("Expected first lookup to win\nexpected: first\nreceived: %s", v)
+	}
+
+	if v, _ := lookup("b"); v != "second" {
+		t.Fatalf("Expected to fall back to next lookup\nexpected: second\nreceived: %s", v)
+	}
+
+	if _, ok := lookup("c"); ok {
+		t.Fatal("Expected undefined variable to not be found")
+	}
+}
+
+func TestExpandTestCase(t *testing.T) {
+	t.Parallel()
+
+	headers := map[string]string{"Authorization": "token ${token}"}
+	cookie := "${user}"
+	expandedCookie := "amad"
+	auth := &core.Auth{OAuth2: &core.OAuth2Auth{TokenURL: "https://${host}/token", ClientID: "${user}", ClientSecret: "${token}", Scopes: []string{"${user}:read"}}}
+	tc := core.TestCase{
+		Name:    "${not-expanded}",
+		URL:     "https://${host}/login",
+		Headers: headers,
+		Body:    `{"user":"${user}"}`,
+		TLS:     core.TLS{Cert: "certs/${user}.pem", Key: "certs/${user}.key"},
+		Network: core.Network{Resolve: []string{"${host}:443:10.0.0.5"}},
+		Auth:    auth,
+		Sign:    &core.Signing{HMAC: &core.HMACSigning{Secret: "${token}", Template: "{method} ${host}", Headers: map[string]string{"X-User": "${user}"}}},
+		Assertions: core.Assertions{
+			Body:            []string{"${user}"},
+			BodyNotContains: []string{"${token}"},
+			HeadersAbsent:   []string{"X-${user}-Debug"},
+			Headers:         map[string]core.HeaderAssertion{"Location": {Value: "https://${host}/home"}},
+			Cookies:         map[string]core.CookieAssertion{"user": {Value: &cookie, Matches: "^${user}"}},
+			FinalURL:        "https://${host}/home",
+			Redirects:       []core.RedirectAssertion{{Location: "https://${host}/home"}},
+			JSON: map[string]core.JSONAssertion{
+				"$.user": {Equals: json.RawMessage(`"${user}"`), Matches: "^${user}$"},
+				"$.id":   {Equals: json.RawMessage(`{"name":"${user}"}`)},
+			},
+		},
+	}
+
+	err := vars.ExpandTestCase(&tc, vars.Map(map[string]string{"host": "example.com", "token": "secret", "user": "amad"}))
+	if err != nil {
+		t.Fatalf("Unexpected error\nexpected: <nil>\nreceived: %s", err.Error())
+	}
+
+	expected := core.TestCase{
+		Name:    "${not-expanded}",
+		URL:     "https://example.com/login",
+		Headers: map[string]string{"Authorization": "token secret"},
+		Body:    `{"user":"amad"}`,
+		TLS:     core.TLS{Cert: "certs/amad.pem", Key: "certs/amad.key"},
+		Network: core.Network{Resolve: []string{"example.com:443:10.0.0.5"}},
+		Auth:    &core.Auth{OAuth2: &core.OAuth2Auth{TokenURL: "https://example.com/token", ClientID: "amad", ClientSecret: "secret", Scopes: []string{"amad:read"}}},
+		Sign:    &core.Signing{HMAC: &core.HMACSigning{Secret: "secret", Template: "{method} example.com", Headers: map[string]string{"X-User": "amad"}}},
+		Assertions: core.Assertions{
+			Body:            []string{"amad"},
+			BodyNotContains: []string{"secret"},
+			HeadersAbsent:   []string{"X-amad-Debug"},
+			Headers:         map[string]core.HeaderAssertion{"Location": {Value: "https://example.com/home"}},
+			Cookies:         map[string]core.CookieAssertion{"user": {Value: &expandedCookie, Matches: "^amad"}},
+			FinalURL:        "https://example.com/home",
+			Redirects:       []core.RedirectAssertion{{Location: "https://example.com/home"}},
+			JSON: map[string]core.JSONAssertion{
+				"$.user": {Equals: json.RawMessage(`"amad"`), Matches: "^amad$"},
+				"$.id":   {Equals: json.RawMessage(`{"name":"${user}"}`)},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, tc) {
+		t.Fatalf("Test case does not match\nexpected: %+v\nreceived: %+v", expected, tc)
+	}
+
+	if headers["Authorization"] != "token ${token}" {
+		t.Fatal("Expected original headers map to be left unchanged")
+	}
+
+	if cookie != "${user}" {
+		t.Fatal("Expected original cookie value to be left unchanged")
+	}
+
+	if auth.OAuth2.ClientSecret != "${token}" || auth.OAuth2.Scopes[0] != "${user}:read" {
+		t.Fatal("Expected original auth to be left unchanged")
+	}
+
+	err = vars.ExpandTestCase(&core.TestCase{Headers: map[string]string{"X-Token": "${missing}"}}, vars.Map(nil))
+	if err == nil || err.Error() != "undefined variable \"missing\" in headers.X-Token" {
+		t.Fatalf("Expected error does not match\nexpected: undefined variable \"missing\" in headers.X-Token\nreceived: %v", err)
+	}
+}